@@ -0,0 +1,647 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aip parses the filter and order_by of the list methods,
+// which are defined by Google AIP-160 and AIP-132, to the operations.
+package aip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xgfone/go-op"
+	"github.com/xgfone/go-op/internal/syntax"
+)
+
+// Error represents the error of the invalid filter or order_by,
+// which does not contain the internal details and can be returned
+// to the client verbatim.
+type Error struct {
+	Param string // "filter" or "order_by"
+	Pos   int    // The byte offset in the input, starting with 1.
+	Msg   string
+}
+
+// Error implements the interface error.
+func (e Error) Error() string {
+	return fmt.Sprintf("invalid %s at position %d: %s", e.Param, e.Pos, e.Msg)
+}
+
+// DefaultParser is the default parser, which allows all the fields.
+var DefaultParser Parser
+
+// ParseFilter is equal to DefaultParser.ParseFilter(filter).
+func ParseFilter(filter string) (op.Condition, error) {
+	return DefaultParser.ParseFilter(filter)
+}
+
+// ParseOrderBy is equal to DefaultParser.ParseOrderBy(orderBy).
+func ParseOrderBy(orderBy string) ([]op.Sorter, error) {
+	return DefaultParser.ParseOrderBy(orderBy)
+}
+
+// Parser is used to parse the filter and order_by.
+type Parser struct {
+	// Key maps the field in the filter or order_by, such as "user.name",
+	// to the key of the operation, and returns false if the field
+	// is not allowed, which is reported as an unknown field.
+	//
+	// If nil, use the field as the key.
+	Key func(field string) (key string, ok bool)
+
+	// Has builds the condition of the has operator ":", such as "tags:urgent".
+	// value is the literal after ":", which is not the wildcard "*" alone,
+	// and the wildcards in it are not processed.
+	//
+	// If nil, the has operator is regarded as the containment of the string,
+	// that's, Like(key, "%value%"), or Like(key, pattern) if the value contains
+	// the wildcards, such as "x*".
+	Has func(key string, value any) (op.Condition, error)
+
+	// Global builds the condition of the global restriction, that's,
+	// the bare value without the comparator, such as "c" in "NOT c".
+	//
+	// If nil, the bare field is regarded as the boolean field,
+	// such as Equal("c", true), and other global restrictions,
+	// such as the quoted string, are not supported.
+	Global func(value any) (op.Condition, error)
+}
+
+// ParseFilter parses the filter to the condition, such as
+//
+//	a = 1 AND b:"x*" OR NOT c
+//
+// The grammar is a subset of AIP-160 as follow:
+//
+//	expression  = sequence { "AND" sequence }
+//	sequence    = factor { factor }
+//	factor      = term { "OR" term }
+//	term        = [ "NOT" | "-" ] simple
+//	simple      = "(" expression ")" | restriction
+//	restriction = field comparator value | global
+//	global      = value
+//	comparator  = "=" | "!=" | "<" | "<=" | ">" | ">=" | ":"
+//
+// The keywords "AND", "OR" and "NOT" are case-sensitive, and "OR" binds
+// tighter than "AND", and the juxtaposed factors in a sequence are joined
+// by "AND". The field is the dot-separated names, such as "user.name".
+// The function call is not supported. For the global restriction,
+// see Parser.Global.
+//
+// The value is quoted by the double or single quotes, or a bare text.
+// The quoted value is always a string, and the bare text is parsed
+// to bool for "true" and "false", nil for "null", int64 or float64
+// for the number, or the string for others.
+//
+// The "*" in the string value is the wildcard, and "\*" matches "*"
+// literally. The "=" and "!=" comparisons with the wildcards are converted
+// to Like and NotLike, "field = null" and "field != null" are converted
+// to IsNull and IsNotNull, and "field:*" is converted to IsNotNull.
+// For the has operator with other values, see Parser.Has.
+//
+// If the filter is empty, return (nil, nil). If failing to parse the filter,
+// return an Error.
+func (p Parser) ParseFilter(filter string) (op.Condition, error) {
+	fp := &filterParser{Parser: p, lexer: lexer{src: filter}}
+	if err := fp.next(); err != nil {
+		return nil, err
+	} else if fp.tok.kind == tokenEOF {
+		return nil, nil
+	}
+
+	cond, err := fp.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if fp.tok.kind != tokenEOF {
+		return nil, fp.errorf("unexpected %s", fp.tok)
+	}
+	return cond, nil
+}
+
+func (p Parser) key(field string) (string, bool) {
+	if !isField(field) {
+		return "", false
+	} else if p.Key == nil {
+		return field, true
+	}
+	return p.Key(field)
+}
+
+func isField(s string) bool {
+	for _, name := range strings.Split(s, ".") {
+		if name == "" {
+			return false
+		}
+
+		for i, r := range name {
+			if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParseOrderBy parses the order_by to the sorters, which is the comma-separated
+// fields and each field may be followed by "asc" or "desc", such as
+//
+//	foo desc, bar.baz
+//
+// If orderBy is empty, return (nil, nil). If failing to parse it, return an Error.
+func (p Parser) ParseOrderBy(orderBy string) ([]op.Sorter, error) {
+	return syntax.OrderBy{
+		Name:     "field",
+		FoldCase: true,
+		Errorf:   orderByErrorf,
+		Key: func(field string, pos int) (string, error) {
+			if key, ok := p.key(field); ok {
+				return key, nil
+			}
+			return "", orderByErrorf(pos, "unknown field '%s'", field)
+		},
+	}.Parse(orderBy)
+}
+
+func orderByErrorf(pos int, format string, args ...any) error {
+	return Error{Param: "order_by", Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+/// ---------------------------------------------------------------------- ///
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenText
+	tokenString
+	tokenComparator
+	tokenMinus
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string // The unescaped text, or the unquoted text for the string.
+	pos  int
+
+	// For the text and string, which is the LIKE pattern converted
+	// from the wildcards if wildcard is true.
+	pattern  string
+	wildcard bool
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenText && t.text == kw
+}
+
+func (t token) isKeyword() bool {
+	return t.keyword("AND") || t.keyword("OR") || t.keyword("NOT")
+}
+
+func isTextRune(r rune) bool {
+	switch r {
+	case '(', ')', '"', '\'', '<', '>', '=', '!', ':', ',':
+		return false
+	default:
+		return !unicode.IsSpace(r)
+	}
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	return Error{Param: "filter", Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		r, n := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += n
+	}
+
+	start := l.pos
+	if start >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	switch c := l.src[start]; c {
+	case '(':
+		l.pos++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+
+	case ')':
+		l.pos++
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+
+	case '<', '>', '=', '!', ':':
+		for _, op := range []string{"<=", ">=", "!=", "<", ">", "=", ":"} {
+			if strings.HasPrefix(l.src[start:], op) {
+				l.pos += len(op)
+				return token{kind: tokenComparator, text: op, pos: start}, nil
+			}
+		}
+		return token{}, l.errorf(start, "unexpected character '%c'", c)
+
+	case '"', '\'':
+		return l.readString(c)
+
+	case '-':
+		if start+1 >= len(l.src) || !('0' <= l.src[start+1] && l.src[start+1] <= '9' || l.src[start+1] == '.') {
+			l.pos++
+			return token{kind: tokenMinus, text: "-", pos: start}, nil
+		}
+	}
+
+	// In the bare text, "\*" and "\\" are the escaped "*" and "\",
+	// and other "\" is regarded literally.
+	var b, like strings.Builder
+	var wildcard bool
+	for l.pos < len(l.src) {
+		r, n := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isTextRune(r) {
+			break
+		}
+
+		switch {
+		case r == '\\' && l.pos+1 < len(l.src) && (l.src[l.pos+1] == '*' || l.src[l.pos+1] == '\\'):
+			r, n = rune(l.src[l.pos+1]), 2
+			writeLikeRune(&like, r, false)
+		default:
+			wildcard = wildcard || r == '*'
+			writeLikeRune(&like, r, true)
+		}
+
+		b.WriteRune(r)
+		l.pos += n
+	}
+
+	if l.pos == start {
+		r, _ := utf8.DecodeRuneInString(l.src[start:])
+		return token{}, l.errorf(start, "unexpected character '%c'", r)
+	}
+
+	return token{
+		kind:     tokenText,
+		text:     b.String(),
+		pos:      start,
+		pattern:  like.String(),
+		wildcard: wildcard,
+	}, nil
+}
+
+func (l *lexer) readString(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var b, like strings.Builder
+	var wildcard bool
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case quote:
+			l.pos++
+			return token{
+				kind:     tokenString,
+				text:     b.String(),
+				pos:      start,
+				pattern:  like.String(),
+				wildcard: wildcard,
+			}, nil
+
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(start, "unterminated string")
+			}
+
+			l.pos++
+			switch c = l.src[l.pos]; c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case '\\', '"', '\'', '*':
+			default:
+				return token{}, l.errorf(l.pos-1, "invalid escape character '\\%c'", c)
+			}
+
+			b.WriteByte(c)
+			writeLikeRune(&like, rune(c), false)
+			l.pos++
+
+		default:
+			r, n := utf8.DecodeRuneInString(l.src[l.pos:])
+			wildcard = wildcard || r == '*'
+			b.WriteString(l.src[l.pos : l.pos+n])
+			writeLikeRune(&like, r, true)
+			l.pos += n
+		}
+	}
+
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+// writeLikeRune writes the rune into the LIKE pattern, which converts
+// the wildcard "*" to "%" if wildcard is true and escapes "%", "_" and "\".
+func writeLikeRune(b *strings.Builder, r rune, wildcard bool) {
+	switch r {
+	case '*':
+		if wildcard {
+			b.WriteByte('%')
+		} else {
+			b.WriteByte('*')
+		}
+	case '%', '_', '\\':
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	default:
+		b.WriteRune(r)
+	}
+}
+
+/// ---------------------------------------------------------------------- ///
+
+type filterParser struct {
+	Parser
+	lexer lexer
+	tok   token
+}
+
+func (p *filterParser) next() (err error) {
+	p.tok, err = p.lexer.next()
+	return
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return p.lexer.errorf(p.tok.pos, format, args...)
+}
+
+func (p *filterParser) parseExpression() (op.Condition, error) {
+	conds, err := p.parseSequence(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.tok.keyword("AND") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+
+		if conds, err = p.parseSequence(conds); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return op.And(conds...), nil
+}
+
+// parseSequence parses the juxtaposed factors and appends them into conds.
+func (p *filterParser) parseSequence(conds []op.Condition) ([]op.Condition, error) {
+	for {
+		cond, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+
+		switch p.tok.kind {
+		case tokenText:
+			if p.tok.keyword("AND") || p.tok.keyword("OR") {
+				return conds, nil
+			}
+		case tokenString, tokenMinus, tokenLParen:
+		default:
+			return conds, nil
+		}
+	}
+}
+
+func (p *filterParser) parseFactor() (op.Condition, error) {
+	return syntax.ParseBinary(p.parseTerm, p.keyword("OR"), op.Or)
+}
+
+// keyword returns a function to consume the keyword kw if it is the current token.
+func (p *filterParser) keyword(kw string) func() (bool, error) {
+	return func() (bool, error) {
+		if !p.tok.keyword(kw) {
+			return false, nil
+		}
+		return true, p.next()
+	}
+}
+
+func (p *filterParser) parseTerm() (op.Condition, error) {
+	if p.tok.keyword("NOT") || p.tok.kind == tokenMinus {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		cond, err := p.parseSimple()
+		if err != nil {
+			return nil, err
+		}
+		return op.Not(cond), nil
+	}
+	return p.parseSimple()
+}
+
+func (p *filterParser) parseSimple() (op.Condition, error) {
+	if p.tok.kind != tokenLParen {
+		return p.parseRestriction()
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	cond, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenRParen {
+		return nil, p.errorf("expect ')', but got %s", p.tok)
+	}
+	return cond, p.next()
+}
+
+func (p *filterParser) parseRestriction() (op.Condition, error) {
+	if p.tok.kind != tokenText && p.tok.kind != tokenString || p.tok.isKeyword() {
+		return nil, p.errorf("expect a field, but got %s", p.tok)
+	}
+
+	field := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.tok.kind == tokenLParen && field.kind == tokenText:
+		return nil, p.lexer.errorf(field.pos, "function '%s' is not supported", field.text)
+	case p.tok.kind != tokenComparator:
+		return p.parseGlobal(field)
+	case field.kind != tokenText:
+		return nil, p.lexer.errorf(field.pos, "expect a field, but got %s", field)
+	}
+
+	key, ok := p.key(field.text)
+	if !ok {
+		return nil, p.lexer.errorf(field.pos, "unknown field '%s'", field.text)
+	}
+
+	comparator := p.tok.text
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	arg := p.tok
+	if arg.kind != tokenText && arg.kind != tokenString || arg.isKeyword() {
+		return nil, p.errorf("expect a value after '%s', but got %s", comparator, arg)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	value := parseValue(arg)
+	switch comparator {
+	case "=":
+		switch {
+		case value == nil:
+			return op.IsNull(key), nil
+		case arg.wildcard:
+			return op.Like(key, arg.pattern), nil
+		default:
+			return op.Equal(key, value), nil
+		}
+
+	case "!=":
+		switch {
+		case value == nil:
+			return op.IsNotNull(key), nil
+		case arg.wildcard:
+			return op.NotLike(key, arg.pattern), nil
+		default:
+			return op.NotEqual(key, value), nil
+		}
+
+	case ":":
+		switch {
+		case arg.kind == tokenText && arg.wildcard && arg.text == "*":
+			return op.IsNotNull(key), nil
+
+		case p.Has != nil:
+			cond, err := p.Has(key, value)
+			if err != nil {
+				return nil, p.lexer.errorf(arg.pos, "%s", err)
+			}
+			return cond, nil
+
+		case arg.wildcard:
+			return op.Like(key, arg.pattern), nil
+
+		default:
+			return op.Like(key, "%"+syntax.EscapeLike(arg.text)+"%"), nil
+		}
+	}
+
+	if value == nil {
+		return nil, p.lexer.errorf(arg.pos, "null cannot be compared by '%s'", comparator)
+	}
+
+	switch comparator {
+	case "<":
+		return op.Less(key, value), nil
+	case "<=":
+		return op.LessEqual(key, value), nil
+	case ">":
+		return op.Greater(key, value), nil
+	default:
+		return op.GreaterEqual(key, value), nil
+	}
+}
+
+func (p *filterParser) parseGlobal(t token) (op.Condition, error) {
+	if p.Global != nil {
+		cond, err := p.Global(parseValue(t))
+		if err != nil {
+			return nil, p.lexer.errorf(t.pos, "%s", err)
+		}
+		return cond, nil
+	}
+
+	if t.kind != tokenText || !isField(t.text) {
+		return nil, p.lexer.errorf(t.pos, "global restriction %s is not supported", t)
+	}
+
+	key, ok := p.key(t.text)
+	if !ok {
+		return nil, p.lexer.errorf(t.pos, "unknown field '%s'", t.text)
+	}
+	return op.Equal(key, true), nil
+}
+
+func parseValue(t token) any {
+	if t.kind == tokenString {
+		return t.text
+	}
+
+	switch t.text {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+
+	if c := t.text[0]; c != '-' && c != '+' && c != '.' && (c < '0' || c > '9') {
+		return t.text
+	}
+
+	if !strings.ContainsAny(t.text, ".eE") {
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return i
+		}
+	}
+	if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return f
+	}
+	return t.text
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aip
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/xgfone/go-op"
+)
+
+func TestParseFilter(t *testing.T) {
+	cond, err := ParseFilter(`a = 1 AND b:"x*" OR NOT c`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := op.And(op.Equal("a", int64(1)), op.Or(op.Like("b", "x%"), op.Not(op.Equal("c", true))))
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	cond, err = ParseFilter(`a = x\*y AND b = x\*y* AND c:\* AND d = x\\y`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect = op.And(op.Equal("a", "x*y"), op.Like("b", "x*y%"), op.Like("c", "%*%"), op.Equal("d", `x\y`))
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	cond, err = ParseFilter(`(name = "a_b\*" OR name != *.com) -deleted:* ` +
+		`tags:urgent AND score >= -1.5 AND owner = null AND title:"50%"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect = op.And(
+		op.Or(op.Equal("name", "a_b*"), op.NotLike("name", "%.com")),
+		op.Not(op.IsNotNull("deleted")),
+		op.Like("tags", "%urgent%"),
+		op.GreaterEqual("score", -1.5),
+		op.IsNull("owner"),
+		op.Like("title", `%50\%%`),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	if cond, err = ParseFilter("  "); err != nil || cond != nil {
+		t.Errorf("expect (nil, nil), but got (%v, %v)", cond, err)
+	}
+}
+
+func TestParserHook(t *testing.T) {
+	p := Parser{
+		Key: func(field string) (string, bool) {
+			switch field {
+			case "createTime":
+				return "created_at", true
+			case "labels":
+				return "labels", true
+			}
+			return "", false
+		},
+		Has: func(key string, value any) (op.Condition, error) {
+			return op.In(key, []any{value}), nil
+		},
+	}
+
+	cond, err := p.ParseFilter(`createTime > "2024-01-01T00:00:00Z" labels:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := op.And(op.Greater("created_at", "2024-01-01T00:00:00Z"), op.In("labels", []any{int64(1)}))
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	_, err = p.ParseFilter(`labels:1 AND name = "a"`)
+	if expect := "invalid filter at position 14: unknown field 'name'"; err == nil || err.Error() != expect {
+		t.Errorf("expect error '%s', but got '%v'", expect, err)
+	}
+
+	p.Global = func(value any) (op.Condition, error) {
+		return op.Like("title", fmt.Sprintf("%%%v%%", value)), nil
+	}
+	cond, err = p.ParseFilter(`"abc" labels:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect = op.And(op.Like("title", "%abc%"), op.In("labels", []any{int64(1)}))
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	sorters, err := p.ParseOrderBy("createTime desc, labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []op.Sorter{op.Order("created_at", op.SortDesc), op.Order("labels", op.SortAsc)}
+	if !reflect.DeepEqual(sorters, expects) {
+		t.Errorf("expect %v, but got %v", expects, sorters)
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	for filter, pos := range map[string]int{
+		`a =`:         4,
+		`a = 1 AND`:   10,
+		`(a = 1`:      7,
+		`a`:           0,
+		`a b = 1`:     0,
+		`"a"`:         1,
+		`NOT 1`:       5,
+		`"a" = 1`:     1,
+		`a < null`:    5,
+		`f(a) = 1`:    1,
+		`a = "x`:      5,
+		`a = 1 OR )`:  10,
+		`a.1 = 1`:     1,
+		`a = 1 ) b`:   7,
+		`a = AND`:     5,
+		`a = "\x"`:    6,
+		`NOT`:         4,
+		`a ~ 1`:       3,
+		`a = 1 b = 2`: 0,
+	} {
+		_, err := ParseFilter(filter)
+		if pos == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", filter, err)
+			}
+			continue
+		}
+
+		var e Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: expect an Error, but got %v", filter, err)
+		} else if e.Param != "filter" || e.Pos != pos {
+			t.Errorf("%s: expect the position %d, but got %d: %s", filter, pos, e.Pos, e.Msg)
+		}
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	sorters, err := ParseOrderBy(" foo desc,bar.baz ,  qux ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []op.Sorter{
+		op.Order("foo", op.SortDesc),
+		op.Order("bar.baz", op.SortAsc),
+		op.Order("qux", op.SortAsc),
+	}
+	if !reflect.DeepEqual(sorters, expects) {
+		t.Errorf("expect %v, but got %v", expects, sorters)
+	}
+
+	for orderBy, expect := range map[string]string{
+		"foo, ":          "invalid order_by at position 5: missing the field",
+		"foo down":       "invalid order_by at position 5: expect 'asc' or 'desc', but got 'down'",
+		"foo, bar asc x": "invalid order_by at position 14: unexpected 'x'",
+		"foo,1bar":       "invalid order_by at position 5: unknown field '1bar'",
+	} {
+		if _, err := ParseOrderBy(orderBy); err == nil || err.Error() != expect {
+			t.Errorf("%s: expect error '%s', but got '%v'", orderBy, expect, err)
+		}
+	}
+}
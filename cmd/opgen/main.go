@@ -0,0 +1,540 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command opgen generates the key variables and the condition helpers
+// for the model structs, which is designed to be used by go generate.
+//
+// A model struct is marked by the directive comment "//opgen:model",
+// which may be followed by the space-separated options:
+//
+//	table=NAME  the table name of the model, default to the snake case of the struct name.
+//	scope       qualify the keys with the table name, such as "users.id".
+//	typed       generate the keys as op.TypedKey[T] instead of op.Op.
+//
+// For example,
+//
+//	//go:generate go run github.com/xgfone/go-op/cmd/opgen
+//
+//	//opgen:model table=users typed
+//	type User struct {
+//		ID        int64  `sql:"id" opgen:"pk"`
+//		Name      string `sql:"name"`
+//		DeletedAt string `sql:"deleted_at"`
+//	}
+//
+// The column name of the field is got from the struct tag, "sql" by default,
+// or the snake case of the field name if the tag does not exist. The field
+// whose tag is "-" is ignored. The primary key is the field tagged by
+// `opgen:"pk"`, or the field named "ID" or "Id".
+//
+// If the model has the column "deleted_at", the soft-delete policy and
+// conditions are generated, whose policy is derived from the field type:
+// NullSoftDelete for the pointer or sql.NullXxx, BoolSoftDelete for bool,
+// IntSoftDelete for the integer, and DefaultSoftDelete for time.Time or string.
+//
+// The fields of the embedded struct declared in the same file are promoted
+// to the model. The other embedded fields cannot be resolved and must be
+// ignored by the tag "-", or an error is returned.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xgfone/go-op/internal/strcase"
+)
+
+const directive = "//opgen:model"
+
+var (
+	tagname = flag.String("tag", "sql", "The name of the struct tag to get the column name.")
+	output  = flag.String("output", "", "The output file, default to FILE_op.go.")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [FILE]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "FILE is default to $GOFILE set by go generate.")
+		fmt.Fprintln(flag.CommandLine.Output())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	filename := flag.Arg(0)
+	if filename == "" {
+		filename = os.Getenv("GOFILE")
+	}
+	if filename == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(filename, *output, *tagname); err != nil {
+		fmt.Fprintln(os.Stderr, "opgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(filename, outfile, tagname string) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	data, err := generate(filename, src, tagname)
+	if err != nil {
+		return err
+	} else if data == nil {
+		return fmt.Errorf("no struct marked by %s in %s", directive, filename)
+	}
+
+	if outfile == "" {
+		outfile = strings.TrimSuffix(filename, filepath.Ext(filename)) + "_op.go"
+	}
+	return os.WriteFile(outfile, data, 0o644)
+}
+
+type model struct {
+	Name   string
+	Table  string
+	Scope  bool
+	Typed  bool
+	Fields []field
+}
+
+type field struct {
+	Name   string
+	Type   string
+	Expr   ast.Expr
+	Column string
+	PK     bool
+}
+
+// generate parses the go source and returns the generated code.
+//
+// If there is no model struct, return (nil, nil).
+func generate(filename string, src []byte, tagname string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := make(map[string]*ast.StructType)
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+
+	imports := make(map[string]string)
+	var models []model
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			doc := ts.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+
+			opts, ok := parseDirective(doc)
+			if !ok {
+				continue
+			}
+
+			m, err := parseModel(ts.Name.Name, st, structs, opts, tagname)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fset.Position(ts.Pos()), err)
+			}
+
+			if m.Typed {
+				for _, f := range m.Fields {
+					collectImports(file, f.Expr, imports)
+				}
+			}
+			models = append(models, m)
+		}
+	}
+
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by opgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", file.Name.Name)
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteString("import (\n")
+	for _, name := range names {
+		if path := imports[name]; pathpkg.Base(path) == name {
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+		}
+	}
+	buf.WriteString("\n\t\"github.com/xgfone/go-op\"\n)\n")
+
+	for _, m := range models {
+		writeModel(&buf, m)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func parseDirective(doc *ast.CommentGroup) (opts []string, ok bool) {
+	if doc == nil {
+		return
+	}
+
+	for _, c := range doc.List {
+		if c.Text == directive || strings.HasPrefix(c.Text, directive+" ") {
+			return strings.Fields(c.Text[len(directive):]), true
+		}
+	}
+	return
+}
+
+func parseModel(name string, st *ast.StructType, structs map[string]*ast.StructType,
+	opts []string, tagname string) (m model, err error) {
+	m.Name = name
+	m.Table = strcase.Snake(name)
+	for _, opt := range opts {
+		switch key, value, _ := strings.Cut(opt, "="); key {
+		case "table":
+			if value == "" {
+				return m, fmt.Errorf("missing the table name of the model %s", name)
+			}
+			m.Table = value
+		case "scope":
+			m.Scope = true
+		case "typed":
+			m.Typed = true
+		default:
+			return m, fmt.Errorf("unknown option '%s' of the model %s", opt, name)
+		}
+	}
+
+	visiting := map[string]struct{}{name: {}}
+	if m.Fields, err = parseFields(name, st, structs, visiting, tagname); err != nil {
+		return
+	}
+
+	for _, f := range m.Fields {
+		if f.PK {
+			return
+		}
+	}
+
+	for i, f := range m.Fields {
+		if f.Name == "ID" || f.Name == "Id" {
+			m.Fields[i].PK = true
+			break
+		}
+	}
+
+	return
+}
+
+// parseFields returns the fields of the struct, including the fields
+// promoted from the embedded structs declared in the same file.
+//
+// Like Go, the field of the struct shadows the promoted fields
+// with the same name. If the embedded struct cannot be resolved,
+// or two embedded structs promote the fields with the same name,
+// return an error, which may be avoided by the tag "-".
+func parseFields(name string, st *ast.StructType, structs map[string]*ast.StructType,
+	visiting map[string]struct{}, tagname string) (fields []field, err error) {
+	var promoted [][]field
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s)
+		}
+
+		column, _, _ := strings.Cut(tag.Get(tagname), ",")
+		if column == "-" {
+			continue
+		}
+
+		if len(f.Names) == 0 {
+			embedded, err := parseEmbedded(name, f.Type, structs, visiting, tagname)
+			if err != nil {
+				return nil, err
+			}
+			promoted = append(promoted, embedded)
+			continue
+		}
+
+		pk := tag.Get("opgen") == "pk"
+		for _, ident := range f.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			_column := column
+			if _column == "" {
+				_column = strcase.Snake(ident.Name)
+			}
+
+			fields = append(fields, field{
+				Name:   ident.Name,
+				Type:   types.ExprString(f.Type),
+				Expr:   f.Type,
+				Column: _column,
+				PK:     pk,
+			})
+		}
+	}
+
+	names := make(map[string]bool, len(fields)) // name -> whether promoted
+	for _, f := range fields {
+		names[f.Name] = false
+	}
+
+	for _, embedded := range promoted {
+		for _, f := range embedded {
+			if isPromoted, ok := names[f.Name]; ok {
+				if isPromoted {
+					return nil, fmt.Errorf("ambiguous promoted field %s of the struct %s", f.Name, name)
+				}
+				continue
+			}
+
+			names[f.Name] = true
+			fields = append(fields, f)
+		}
+	}
+
+	return
+}
+
+func parseEmbedded(name string, expr ast.Expr, structs map[string]*ast.StructType,
+	visiting map[string]struct{}, tagname string) ([]field, error) {
+	typ := expr
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+
+	ident, ok := typ.(*ast.Ident)
+	if !ok || structs[ident.Name] == nil {
+		return nil, fmt.Errorf("cannot resolve the embedded field %s of the struct %s,"+
+			" which is not a struct declared in the same file; tag it with `%s:\"-\"` to ignore it",
+			types.ExprString(expr), name, tagname)
+	}
+
+	if _, ok := visiting[ident.Name]; ok {
+		return nil, fmt.Errorf("recursive embedded struct %s in the struct %s", ident.Name, name)
+	}
+
+	visiting[ident.Name] = struct{}{}
+	defer delete(visiting, ident.Name)
+	return parseFields(ident.Name, structs[ident.Name], structs, visiting, tagname)
+}
+
+func collectImports(file *ast.File, expr ast.Expr, imports map[string]string) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			if path, ok := lookupImport(file, ident.Name); ok {
+				imports[ident.Name] = path
+			}
+		}
+		return false
+	})
+}
+
+// lookupImport returns the path of the import referred by name.
+//
+// If the import has no explicit name, the package name is guessed
+// by the conventions of the import path, such as "yaml" for "gopkg.in/yaml.v3",
+// "chi" for "github.com/go-chi/chi/v5", and "op" for "github.com/xgfone/go-op".
+// If no convention matches, the packages are looked up by go/build.
+func lookupImport(file *ast.File, name string) (path string, ok bool) {
+	for _, imp := range file.Imports {
+		path, _ = strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil {
+			if imp.Name.Name == name {
+				return path, true
+			}
+		} else if guessImportName(path, name) {
+			return path, true
+		}
+	}
+
+	for _, imp := range file.Imports {
+		if imp.Name == nil {
+			path, _ = strconv.Unquote(imp.Path.Value)
+			if pkg, err := build.Import(path, ".", 0); err == nil && pkg.Name == name {
+				return path, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func guessImportName(path, name string) bool {
+
+	base := pathpkg.Base(path)
+	if isMajorVersion(base) && strings.Contains(path, "/") {
+		base = pathpkg.Base(pathpkg.Dir(path))
+	}
+	if i := strings.LastIndex(base, ".v"); i > 0 && isMajorVersion(base[i+1:]) {
+		base = base[:i]
+	}
+
+	for _, guess := range []string{
+		base,
+		strings.TrimPrefix(base, "go-"),
+		strings.TrimSuffix(base, "-go"),
+		strings.TrimSuffix(base, ".go"),
+	} {
+		if guess == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 10, 64)
+	return err == nil
+}
+
+// softDeletePolicy returns the expression of the soft-delete policy
+// by the type of the field "deleted_at", or "" if the type is unknown.
+//
+//	*T and sql.NullXxx:       op.NullSoftDelete
+//	bool:                     op.BoolSoftDelete
+//	integer:                  op.IntSoftDelete
+//	time.Time and string:     op.DefaultSoftDelete, that is, the zero datetime
+func softDeletePolicy(f field) string {
+	switch {
+	case strings.HasPrefix(f.Type, "*"), strings.HasPrefix(f.Type, "sql.Null"):
+		return fmt.Sprintf("op.NullSoftDelete(%q)", f.Column)
+
+	case f.Type == "bool":
+		return fmt.Sprintf("op.BoolSoftDelete(%q)", f.Column)
+
+	case f.Type == "int", f.Type == "int32", f.Type == "int64",
+		f.Type == "uint", f.Type == "uint32", f.Type == "uint64":
+		return fmt.Sprintf("op.IntSoftDelete(%q)", f.Column)
+
+	case f.Type == "time.Time", f.Type == "string":
+		return "op.DefaultSoftDelete"
+
+	default:
+		return ""
+	}
+}
+
+func writeModel(buf *bytes.Buffer, m model) {
+	fmt.Fprintf(buf, "\n// %sTable is the table name of the model %s.\n", m.Name, m.Name)
+	fmt.Fprintf(buf, "const %sTable = %q\n", m.Name, m.Table)
+
+	fmt.Fprintf(buf, "\n// Pre-define the keys of the model %s.\n", m.Name)
+	buf.WriteString("var (\n")
+	for _, f := range m.Fields {
+		column := f.Column
+		if m.Scope {
+			column = m.Table + "." + column
+		}
+
+		if m.Typed {
+			fmt.Fprintf(buf, "\t%sKey%s = op.NewTypedKey[%s](%q)\n", m.Name, f.Name, f.Type, column)
+		} else {
+			fmt.Fprintf(buf, "\t%sKey%s = op.Key(%q)\n", m.Name, f.Name, column)
+		}
+	}
+	buf.WriteString(")\n")
+
+	for _, f := range m.Fields {
+		if f.PK {
+			vtype := "any"
+			if m.Typed {
+				vtype = f.Type
+			}
+
+			fmt.Fprintf(buf, "\n// %sBy%s returns the condition to look up the model %s by the primary key.\n",
+				m.Name, f.Name, m.Name)
+			fmt.Fprintf(buf, "func %sBy%s(value %s) op.Condition { return %sKey%s.Eq(value) }\n",
+				m.Name, f.Name, vtype, m.Name, f.Name)
+			break
+		}
+	}
+
+	for _, f := range m.Fields {
+		if f.Column != "deleted_at" {
+			continue
+		}
+
+		policy := softDeletePolicy(f)
+		if policy == "" {
+			break
+		}
+		if m.Scope {
+			policy = fmt.Sprintf("%s.Scope(%q)", policy, m.Table)
+		}
+
+		fmt.Fprintf(buf, "\n// Pre-define the soft-delete policy and conditions of the model %s.\n", m.Name)
+		buf.WriteString("var (\n")
+		fmt.Fprintf(buf, "\t%sSoftDelete = %s\n", m.Name, policy)
+		fmt.Fprintf(buf, "\t%sDeleted    = %sSoftDelete.Deleted()\n", m.Name, m.Name)
+		fmt.Fprintf(buf, "\t%sNotDeleted = %sSoftDelete.NotDeleted()\n", m.Name, m.Name)
+		buf.WriteString(")\n")
+		break
+	}
+}
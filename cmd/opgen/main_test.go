@@ -0,0 +1,183 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testsrc = `package models
+
+import "time"
+
+//opgen:model table=users scope typed
+type User struct {
+	ID        int64     ` + "`sql:\"id\"`" + `
+	UserName  string
+	CreatedAt time.Time ` + "`sql:\"created_at\"`" + `
+	DeletedAt time.Time ` + "`sql:\"deleted_at\"`" + `
+	Ignored   string    ` + "`sql:\"-\"`" + `
+	private   string
+}
+
+// Order is not a model.
+type Order struct {
+	ID int64
+}
+`
+
+func TestGenerate(t *testing.T) {
+	data, err := generate("models.go", []byte(testsrc), "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := string(data)
+	for _, s := range []string{
+		"package models",
+		`"time"`,
+		`const UserTable = "users"`,
+		`UserKeyID        = op.NewTypedKey[int64]("users.id")`,
+		`UserKeyUserName  = op.NewTypedKey[string]("users.user_name")`,
+		`UserKeyCreatedAt = op.NewTypedKey[time.Time]("users.created_at")`,
+		`func UserByID(value int64) op.Condition { return UserKeyID.Eq(value) }`,
+		`UserSoftDelete = op.DefaultSoftDelete.Scope("users")`,
+		`UserNotDeleted = UserSoftDelete.NotDeleted()`,
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing '%s' in the generated code:\n%s", s, code)
+		}
+	}
+
+	for _, s := range []string{"Ignored", "private", "Order"} {
+		if strings.Contains(code, s) {
+			t.Errorf("unexpected '%s' in the generated code:\n%s", s, code)
+		}
+	}
+}
+
+func TestGenerateEmbedded(t *testing.T) {
+	const src = `package models
+
+import "time"
+
+type Base struct {
+	ID        int64
+	CreatedAt time.Time
+}
+
+//opgen:model typed
+type Order struct {
+	*Base
+	ID     string
+	Amount int64
+}
+`
+
+	data, err := generate("models.go", []byte(src), "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := string(data)
+	for _, s := range []string{
+		`"time"`,
+		`OrderKeyID        = op.NewTypedKey[string]("id")`,
+		`OrderKeyAmount    = op.NewTypedKey[int64]("amount")`,
+		`OrderKeyCreatedAt = op.NewTypedKey[time.Time]("created_at")`,
+		`func OrderByID(value string) op.Condition { return OrderKeyID.Eq(value) }`,
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing '%s' in the generated code:\n%s", s, code)
+		}
+	}
+
+	for i, src := range []string{
+		"package models\n\nimport \"sync\"\n\n//opgen:model\ntype Order struct {\n\tsync.Mutex\n\tID int64\n}\n",
+		"package models\n\ntype A struct{ ID int64 }\ntype B struct{ ID int64 }\n\n//opgen:model\ntype Order struct {\n\tA\n\tB\n}\n",
+		"package models\n\n//opgen:model\ntype Order struct {\n\t*Order\n\tID int64\n}\n",
+	} {
+		if _, err := generate("models.go", []byte(src), "sql"); err == nil {
+			t.Errorf("%d: expect an error, but got nil", i)
+		}
+	}
+
+	src2 := "package models\n\nimport \"sync\"\n\n//opgen:model\ntype Order struct {\n\tsync.Mutex `sql:\"-\"`\n\tID int64\n}\n"
+	if _, err := generate("models.go", []byte(src2), "sql"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerateSoftDelete(t *testing.T) {
+	for i, c := range []struct {
+		typ    string
+		expect string
+	}{
+		{"*time.Time", `OrderSoftDelete = op.NullSoftDelete("deleted_at")`},
+		{"sql.NullTime", `OrderSoftDelete = op.NullSoftDelete("deleted_at")`},
+		{"bool", `OrderSoftDelete = op.BoolSoftDelete("deleted_at")`},
+		{"int64", `OrderSoftDelete = op.IntSoftDelete("deleted_at")`},
+		{"time.Time", `OrderSoftDelete = op.DefaultSoftDelete`},
+		{"float64", ""},
+	} {
+		src := "package models\n\nimport (\n\t\"database/sql\"\n\t\"time\"\n)\n\n" +
+			"//opgen:model table=orders\ntype Order struct {\n\tID int64\n\tDeletedAt " + c.typ + "\n}\n" +
+			"\nvar _ sql.NullTime\nvar _ time.Time\n"
+
+		data, err := generate("models.go", []byte(src), "sql")
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if code := string(data); c.expect == "" && strings.Contains(code, "SoftDelete") {
+			t.Errorf("%d: unexpected soft-delete policy in the generated code:\n%s", i, code)
+		} else if !strings.Contains(code, c.expect) {
+			t.Errorf("%d: missing '%s' in the generated code:\n%s", i, c.expect, code)
+		}
+	}
+}
+
+func TestGenerateImports(t *testing.T) {
+	const src = `package models
+
+import (
+	"gopkg.in/yaml.v3"
+	"github.com/go-chi/chi/v5"
+	myop "github.com/xgfone/go-op"
+)
+
+//opgen:model typed
+type Config struct {
+	Node   yaml.Node
+	Router chi.Router
+	Cond   myop.Condition
+}
+`
+
+	data, err := generate("models.go", []byte(src), "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := string(data)
+	for _, s := range []string{
+		`yaml "gopkg.in/yaml.v3"`,
+		`chi "github.com/go-chi/chi/v5"`,
+		`myop "github.com/xgfone/go-op"`,
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing '%s' in the generated code:\n%s", s, code)
+		}
+	}
+}
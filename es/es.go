@@ -0,0 +1,381 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package es converts the operations to the query DSL of Elasticsearch
+// or OpenSearch, which are the JSON-ready maps.
+package es
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xgfone/go-op"
+)
+
+// ErrUnsupported represents that the operation has no query DSL equivalent.
+var ErrUnsupported = errors.New("unsupported by Elasticsearch")
+
+// FieldType is the type of the field.
+type FieldType int
+
+// Pre-define some field types.
+const (
+	// Keyword is the field for the exact values, which uses the term-level queries.
+	Keyword FieldType = iota
+
+	// Text is the analyzed field for the full text, which uses match_phrase
+	// for the equality.
+	Text
+)
+
+// DefaultRenderer is the default renderer, which regards all the fields as Keyword.
+var DefaultRenderer Renderer
+
+// Query is equal to DefaultRenderer.Query(cond).
+func Query(cond op.Condition) (map[string]any, error) {
+	return DefaultRenderer.Query(cond)
+}
+
+// Sort is equal to DefaultRenderer.Sort(sorters...).
+func Sort(sorters ...op.Sorter) ([]any, error) {
+	return DefaultRenderer.Sort(sorters...)
+}
+
+// Search is equal to DefaultRenderer.Search(cond, page, sorters...).
+func Search(cond op.Condition, page op.Pagination, sorters ...op.Sorter) (map[string]any, error) {
+	return DefaultRenderer.Search(cond, page, sorters...)
+}
+
+// Renderer is used to convert the operations to the query DSL.
+type Renderer struct {
+	// Fields is the mapping from the key to the field type.
+	//
+	// If the key does not exist, it is regarded as Keyword.
+	Fields map[string]FieldType
+
+	// KeywordSubfield is the name of the keyword subfield of the text field,
+	// such as "keyword", which is used by the term-level queries and sort
+	// on the text field, such as range, wildcard and terms.
+	//
+	// If empty, use the text field itself.
+	KeywordSubfield string
+}
+
+// field returns the field name of the key. If exact is true,
+// try to use the keyword subfield for the text field.
+func (r Renderer) field(key string, exact bool) (name string, text bool) {
+	if r.Fields[key] != Text {
+		return key, false
+	}
+
+	if exact && r.KeywordSubfield != "" {
+		return key + "." + r.KeywordSubfield, false
+	}
+	return key, true
+}
+
+// Query converts the condition to the query DSL.
+//
+// If cond is nil, return the match_all query.
+//
+// The *Key condition operations, such as CondOpEqualKey, are not supported.
+//
+// If cond contains any unbound op.Param, return an op.BindError.
+// And op.Expr is not supported, and op.RelTime is resolved by op.Prepare.
+func (r Renderer) Query(cond op.Condition) (map[string]any, error) {
+	cond, params, hasExpr := op.Prepare(cond)
+	if len(params) > 0 {
+		return nil, fmt.Errorf("es: %w", op.BindError{Missing: params})
+	}
+	if hasExpr {
+		return nil, fmt.Errorf("es: %w: expression", ErrUnsupported)
+	}
+	return r.query(cond)
+}
+
+func (r Renderer) query(cond op.Condition) (map[string]any, error) {
+	if cond == nil {
+		return map[string]any{"match_all": map[string]any{}}, nil
+	}
+
+	o := cond.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case op.CondOpEqual:
+		return r.equal(o.Key, o.Val), nil
+
+	case op.CondOpNotEqual:
+		if o.Val == nil {
+			return exists(o.Key), nil
+		}
+		return mustNot(r.equal(o.Key, o.Val)), nil
+
+	case op.CondOpIn, op.CondOpNotIn:
+		query, err := r.in(o.Key, o.Val)
+		if err != nil {
+			return nil, err
+		} else if o.Op == op.CondOpNotIn {
+			query = mustNot(query)
+		}
+		return query, nil
+
+	case op.CondOpLess:
+		return r.rangeQuery(o.Key, "lt", o.Val), nil
+	case op.CondOpLessEqual:
+		return r.rangeQuery(o.Key, "lte", o.Val), nil
+	case op.CondOpGreater:
+		return r.rangeQuery(o.Key, "gt", o.Val), nil
+	case op.CondOpGreaterEqual:
+		return r.rangeQuery(o.Key, "gte", o.Val), nil
+
+	case op.CondOpBetween, op.CondOpNotBetween:
+		b, ok := o.Val.(op.Boundary)
+		if !ok {
+			return nil, fmt.Errorf("es: the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		}
+
+		name, _ := r.field(o.Key, true)
+		query := map[string]any{"range": map[string]any{name: map[string]any{"gte": b.Lower, "lte": b.Upper}}}
+		if o.Op == op.CondOpNotBetween {
+			query = mustNot(query)
+		}
+		return query, nil
+
+	case op.CondOpIsNull:
+		return mustNot(exists(o.Key)), nil
+	case op.CondOpIsNotNull:
+		return exists(o.Key), nil
+
+	case op.CondOpLike, op.CondOpNotLike:
+		pattern, ok := o.Val.(string)
+		if !ok {
+			return nil, fmt.Errorf("es: the value of %s must be a string, but got %T", o.Op, o.Val)
+		}
+
+		name, _ := r.field(o.Key, true)
+		query := map[string]any{"wildcard": map[string]any{name: map[string]any{"value": LikeToWildcard(pattern)}}}
+		if o.Op == op.CondOpNotLike {
+			query = mustNot(query)
+		}
+		return query, nil
+
+	case op.CondOpAnd, op.CondOpOr:
+		conds, _ := o.Val.([]op.Condition)
+		queries := make([]any, 0, len(conds))
+		for _, c := range conds {
+			if c == nil {
+				continue
+			}
+
+			query, err := r.query(c)
+			if err != nil {
+				return nil, err
+			}
+			queries = append(queries, query)
+		}
+
+		switch {
+		case len(queries) == 1:
+			return queries[0].(map[string]any), nil
+		case len(queries) == 0 && o.Op == op.CondOpAnd:
+			return map[string]any{"match_all": map[string]any{}}, nil
+		case len(queries) == 0:
+			return map[string]any{"match_none": map[string]any{}}, nil
+		case o.Op == op.CondOpAnd:
+			return map[string]any{"bool": map[string]any{"must": queries}}, nil
+		default:
+			return map[string]any{"bool": map[string]any{"should": queries, "minimum_should_match": 1}}, nil
+		}
+
+	case op.CondOpNot:
+		c, _ := o.Val.(op.Condition)
+		query, err := r.query(c)
+		if err != nil {
+			return nil, err
+		}
+		return mustNot(query), nil
+
+	default:
+		return nil, fmt.Errorf("es: %w: condition operation '%s'", ErrUnsupported, o.Op)
+	}
+}
+
+// equal returns the query that the key is equal to the value.
+//
+// Like IsNull, the nil value is converted to the missing field,
+// since Elasticsearch does not support the term query by null.
+func (r Renderer) equal(key string, value any) map[string]any {
+	if value == nil {
+		return mustNot(exists(key))
+	}
+
+	if name, text := r.field(key, false); text {
+		return map[string]any{"match_phrase": map[string]any{name: value}}
+	}
+	return map[string]any{"term": map[string]any{key: value}}
+}
+
+func (r Renderer) in(key string, values any) (map[string]any, error) {
+	name, text := r.field(key, true)
+	if !text {
+		return map[string]any{"terms": map[string]any{name: values}}, nil
+	}
+
+	vs := reflect.ValueOf(values)
+	if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+		return nil, fmt.Errorf("es: the value of In must be a slice, but got %T", values)
+	}
+
+	queries := make([]any, vs.Len())
+	for i := range queries {
+		queries[i] = map[string]any{"match_phrase": map[string]any{name: vs.Index(i).Interface()}}
+	}
+	return map[string]any{"bool": map[string]any{"should": queries, "minimum_should_match": 1}}, nil
+}
+
+func (r Renderer) rangeQuery(key, op string, value any) map[string]any {
+	name, _ := r.field(key, true)
+	return map[string]any{"range": map[string]any{name: map[string]any{op: value}}}
+}
+
+func exists(key string) map[string]any {
+	return map[string]any{"exists": map[string]any{"field": key}}
+}
+
+func mustNot(query map[string]any) map[string]any {
+	return map[string]any{"bool": map[string]any{"must_not": []any{query}}}
+}
+
+// LikeToWildcard converts the pattern of the LIKE condition to the wildcard pattern,
+// in which "%" is converted to "*", "_" is converted to "?", and the character
+// escaped by "\" is matched literally.
+func LikeToWildcard(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern) + 4)
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			b.WriteByte('*')
+		case '_':
+			b.WriteByte('?')
+		case '*', '?':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				if c = pattern[i]; c == '*' || c == '?' || c == '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(c)
+			} else {
+				b.WriteString(`\\`)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// Sort converts the sorters to the sort DSL, such as [{"age": {"order": "desc"}}].
+func (r Renderer) Sort(sorters ...op.Sorter) ([]any, error) {
+	return r.appendSort(make([]any, 0, len(sorters)), sorters)
+}
+
+func (r Renderer) appendSort(sorts []any, sorters []op.Sorter) ([]any, error) {
+	var err error
+	for _, s := range sorters {
+		if s == nil {
+			continue
+		}
+
+		switch o := s.Op(); o.Op {
+		case op.SortOpOrders:
+			if sorts, err = r.appendSort(sorts, o.Val.([]op.Sorter)); err != nil {
+				return nil, err
+			}
+
+		case op.SortOpOrder:
+			var order string
+			switch o.Val {
+			case op.SortAsc:
+				order = "asc"
+			case op.SortDesc:
+				order = "desc"
+			default:
+				return nil, fmt.Errorf("es: invalid sort order '%v'", o.Val)
+			}
+
+			name, _ := r.field(o.Key, true)
+			sorts = append(sorts, map[string]any{name: map[string]any{"order": order}})
+
+		default:
+			return nil, fmt.Errorf("es: %w: sort operation '%s'", ErrUnsupported, o.Op)
+		}
+	}
+	return sorts, nil
+}
+
+// Search returns the body of the search request, which contains
+// the query, sort, from and size.
+//
+// For OffsetLimiter without the limit, size is not contained in the body.
+//
+// page and sorters may be empty, which are not contained in the body.
+func (r Renderer) Search(cond op.Condition, page op.Pagination, sorters ...op.Sorter) (map[string]any, error) {
+	query, err := r.Query(cond)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{"query": query}
+	if len(sorters) > 0 {
+		sorts, err := r.Sort(sorters...)
+		if err != nil {
+			return nil, err
+		}
+		body["sort"] = sorts
+	}
+
+	if page != nil {
+		switch v := page.Op().Val.(type) {
+		case op.PageSizer:
+			if v.Page > 1 {
+				body["from"] = (v.Page - 1) * v.Size
+			} else {
+				body["from"] = 0
+			}
+			body["size"] = v.Size
+
+		case op.OffsetLimiter:
+			body["from"] = v.Offset
+			if v.Size > 0 {
+				body["size"] = v.Size
+			}
+
+		default:
+			return nil, fmt.Errorf("es: %w: pagination operation '%s'", ErrUnsupported, page.Op().Op)
+		}
+	}
+
+	return body, nil
+}
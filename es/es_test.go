@@ -0,0 +1,70 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package es
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/xgfone/go-op"
+)
+
+func TestRenderer(t *testing.T) {
+	r := Renderer{Fields: map[string]FieldType{"name": Text}, KeywordSubfield: "keyword"}
+	body, err := r.Search(
+		op.And(
+			op.Eq("name", "Aaron"),
+			op.Or(op.In("status", []string{"a", "b"}), op.Like("name", "a%_*")),
+			op.Not(op.Between("age", 10, 20)),
+			op.IsNull("deleted_at"),
+		),
+		op.PageSize(2, 10),
+		op.Order("name", op.SortAsc), op.Order("age", op.SortDesc),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(body)
+	expect := `{"from":10,"query":{"bool":{"must":[` +
+		`{"match_phrase":{"name":"Aaron"}},` +
+		`{"bool":{"minimum_should_match":1,"should":[{"terms":{"status":["a","b"]}},{"wildcard":{"name.keyword":{"value":"a*?\\*"}}}]}},` +
+		`{"bool":{"must_not":[{"range":{"age":{"gte":10,"lte":20}}}]}},` +
+		`{"bool":{"must_not":[{"exists":{"field":"deleted_at"}}]}}]}},` +
+		`"size":10,"sort":[{"name.keyword":{"order":"asc"}},{"age":{"order":"desc"}}]}`
+	if s := string(data); s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+
+	for i, c := range []struct {
+		cond   op.Condition
+		expect string
+	}{
+		{op.Eq("a", nil), `{"bool":{"must_not":[{"exists":{"field":"a"}}]}}`},
+		{op.NotEq("a", nil), `{"exists":{"field":"a"}}`},
+	} {
+		query, err := Query(c.cond)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if data, _ := json.Marshal(query); string(data) != c.expect {
+			t.Errorf("%d: expect '%s', but got '%s'", i, c.expect, data)
+		}
+	}
+
+	if _, err := Query(op.LessKey("a", "b")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expect ErrUnsupported, but got %v", err)
+	}
+}
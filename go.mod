@@ -1,3 +1,8 @@
 module github.com/xgfone/go-op
 
-go 1.18
+go 1.23
+
+require (
+	github.com/go-chi/chi/v5 v5.3.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,30 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strcase
+
+import "testing"
+
+func TestSnake(t *testing.T) {
+	for s, expect := range map[string]string{
+		"ID":        "id",
+		"UserID":    "user_id",
+		"HTTPProxy": "http_proxy",
+		"CreatedAt": "created_at",
+	} {
+		if result := Snake(s); result != expect {
+			t.Errorf("%s: expect '%s', but got '%s'", s, expect, result)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package syntax provides the common helpers of the query languages,
+// such as AIP-160, OData and RSQL.
+package syntax
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/xgfone/go-op"
+)
+
+// SplitFields splits s around the whitespaces, and returns the fields
+// and their offsets, which are added by base.
+func SplitFields(s string, base int) (fields []string, poses []int) {
+	start := -1
+	for i, r := range s + " " {
+		switch {
+		case !unicode.IsSpace(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0:
+			fields = append(fields, s[start:i])
+			poses = append(poses, base+start)
+			start = -1
+		}
+	}
+	return
+}
+
+// EscapeLike escapes the wildcards "%" and "_" and the escape character "\"
+// in s, so that the LIKE pattern matches s literally.
+func EscapeLike(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ParseBinary parses the operands by parse, which are separated by
+// the operator, such as "a and b and c", and joins them by join
+// if there are more than one.
+//
+// operator reports whether the next token is the operator, and consumes it if true.
+func ParseBinary(parse func() (op.Condition, error), operator func() (bool, error),
+	join func(...op.Condition) op.Condition) (op.Condition, error) {
+	cond, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	conds := []op.Condition{cond}
+	for {
+		if ok, err := operator(); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+
+		if cond, err = parse(); err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return join(conds...), nil
+}
+
+// NeedParen reports whether the sub-condition c of the condition operation
+// parent needs the parentheses in the infix syntax, where "and" binds tighter
+// than "or", which keeps the structure of the condition tree after being parsed.
+func NeedParen(parent string, c op.Condition) bool {
+	if c == nil {
+		return false
+	}
+
+	switch o := c.Op(); o.Op {
+	case op.CondOpAnd:
+		return parent != op.CondOpOr && len(o.Val.([]op.Condition)) > 1
+	case op.CondOpOr:
+		return len(o.Val.([]op.Condition)) > 1
+	default:
+		return false
+	}
+}
+
+// OrderBy is used to parse the comma-separated fields to the sorters,
+// and each field may be followed by "asc" or "desc", such as
+//
+//	foo desc, bar
+type OrderBy struct {
+	// Name is the name of the field in the error message,
+	// such as "field" or "property".
+	Name string
+
+	// If true, "asc" and "desc" are case-insensitive.
+	FoldCase bool
+
+	// Key converts the field at the byte offset pos to the key of the sorter.
+	Key func(field string, pos int) (key string, err error)
+
+	// Errorf returns the error at the byte offset pos.
+	Errorf func(pos int, format string, args ...any) error
+}
+
+// Parse parses s to the sorters. If s is empty, return (nil, nil).
+func (o OrderBy) Parse(s string) ([]op.Sorter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var start int
+	parts := strings.Split(s, ",")
+	sorters := make([]op.Sorter, 0, len(parts))
+	for _, part := range parts {
+		fields, poses := SplitFields(part, start)
+		if len(fields) == 0 {
+			return nil, o.Errorf(start, "missing the %s", o.Name)
+		}
+		start += len(part) + 1
+
+		key, err := o.Key(fields[0], poses[0])
+		if err != nil {
+			return nil, err
+		}
+
+		order := op.SortAsc
+		if len(fields) > 1 {
+			switch {
+			case o.isOrder(fields[1], "asc"):
+			case o.isOrder(fields[1], "desc"):
+				order = op.SortDesc
+			default:
+				return nil, o.Errorf(poses[1], "expect 'asc' or 'desc', but got '%s'", fields[1])
+			}
+		}
+
+		if len(fields) > 2 {
+			return nil, o.Errorf(poses[2], "unexpected '%s'", fields[2])
+		}
+
+		sorters = append(sorters, op.Key(key).Order(order))
+	}
+
+	return sorters, nil
+}
+
+func (o OrderBy) isOrder(s, order string) bool {
+	if o.FoldCase {
+		return strings.EqualFold(s, order)
+	}
+	return s == order
+}
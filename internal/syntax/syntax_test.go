@@ -0,0 +1,111 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syntax
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/xgfone/go-op"
+)
+
+func TestSplitFields(t *testing.T) {
+	fields, poses := SplitFields(" foo  bar\tbaz ", 10)
+	if expect := []string{"foo", "bar", "baz"}; !reflect.DeepEqual(fields, expect) {
+		t.Errorf("expect fields %v, but got %v", expect, fields)
+	}
+	if expect := []int{11, 16, 20}; !reflect.DeepEqual(poses, expect) {
+		t.Errorf("expect poses %v, but got %v", expect, poses)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if s, expect := EscapeLike(`a%b_c\d*`), `a\%b\_c\\d*`; s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+}
+
+func TestParseBinary(t *testing.T) {
+	tokens := []string{"a", "+", "b", "+", "c"}
+	parse := func() (op.Condition, error) {
+		cond := op.IsNull(tokens[0])
+		tokens = tokens[1:]
+		return cond, nil
+	}
+	operator := func() (bool, error) {
+		if len(tokens) == 0 || tokens[0] != "+" {
+			return false, nil
+		}
+		tokens = tokens[1:]
+		return true, nil
+	}
+
+	cond, err := ParseBinary(parse, operator, op.Or)
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := op.Or(op.IsNull("a"), op.IsNull("b"), op.IsNull("c")); !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+}
+
+func TestNeedParen(t *testing.T) {
+	and := op.And(op.IsNull("a"), op.IsNull("b"))
+	or := op.Or(op.IsNull("a"), op.IsNull("b"))
+	for i, c := range []struct {
+		parent string
+		cond   op.Condition
+		paren  bool
+	}{
+		{op.CondOpOr, and, false},
+		{op.CondOpAnd, and, true},
+		{op.CondOpNot, and, true},
+		{op.CondOpAnd, or, true},
+		{op.CondOpOr, or, true},
+		{op.CondOpAnd, op.Or(op.IsNull("a")), false},
+		{op.CondOpAnd, op.IsNull("a"), false},
+		{op.CondOpAnd, nil, false},
+	} {
+		if paren := NeedParen(c.parent, c.cond); paren != c.paren {
+			t.Errorf("%d: expect %v, but got %v", i, c.paren, paren)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	o := OrderBy{
+		Name: "field",
+		Key:  func(field string, pos int) (string, error) { return field, nil },
+		Errorf: func(pos int, format string, args ...any) error {
+			return fmt.Errorf("%d: "+format, append([]any{pos}, args...)...)
+		},
+	}
+
+	sorters, err := o.Parse("a desc, b")
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := []op.Sorter{op.Order("a", op.SortDesc), op.Order("b", op.SortAsc)}; !reflect.DeepEqual(sorters, expect) {
+		t.Errorf("expect %v, but got %v", expect, sorters)
+	}
+
+	if _, err := o.Parse("a DESC"); err == nil || err.Error() != "2: expect 'asc' or 'desc', but got 'DESC'" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	o.FoldCase = true
+	if _, err := o.Parse("a DESC"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mongo converts the operations to the MongoDB documents,
+// which are the plain maps without depending on the MongoDB driver.
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/xgfone/go-op"
+)
+
+// ErrUnsupported represents that the operation has no MongoDB equivalent.
+var ErrUnsupported = errors.New("unsupported by MongoDB")
+
+// Filter converts the condition to the MongoDB filter document.
+//
+// If cond is nil, return an empty document.
+//
+// The *Key condition operations, such as CondOpEqualKey, are not supported,
+// which need $expr.
+//
+// If cond contains any unbound op.Param, return an op.BindError.
+// And op.Expr is not supported, and op.RelTime is resolved by op.Prepare.
+func Filter(cond op.Condition) (map[string]any, error) {
+	cond, params, hasExpr := op.Prepare(cond)
+	if len(params) > 0 {
+		return nil, fmt.Errorf("mongo: %w", op.BindError{Missing: params})
+	}
+	if hasExpr {
+		return nil, fmt.Errorf("mongo: %w: expression", ErrUnsupported)
+	}
+	return filter(cond)
+}
+
+func filter(cond op.Condition) (map[string]any, error) {
+	if cond == nil {
+		return map[string]any{}, nil
+	}
+
+	o := cond.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case op.CondOpEqual:
+		return field(o.Key, "$eq", o.Val), nil
+	case op.CondOpNotEqual:
+		return field(o.Key, "$ne", o.Val), nil
+	case op.CondOpLess:
+		return field(o.Key, "$lt", o.Val), nil
+	case op.CondOpLessEqual:
+		return field(o.Key, "$lte", o.Val), nil
+	case op.CondOpGreater:
+		return field(o.Key, "$gt", o.Val), nil
+	case op.CondOpGreaterEqual:
+		return field(o.Key, "$gte", o.Val), nil
+	case op.CondOpIn:
+		return field(o.Key, "$in", o.Val), nil
+	case op.CondOpNotIn:
+		return field(o.Key, "$nin", o.Val), nil
+	case op.CondOpIsNull:
+		return field(o.Key, "$eq", nil), nil
+	case op.CondOpIsNotNull:
+		return map[string]any{o.Key: map[string]any{"$exists": true, "$ne": nil}}, nil
+
+	case op.CondOpLike, op.CondOpNotLike:
+		pattern, ok := o.Val.(string)
+		if !ok {
+			return nil, fmt.Errorf("mongo: the value of %s must be a string, but got %T", o.Op, o.Val)
+		}
+
+		regex := map[string]any{"$regex": LikeToRegex(pattern)}
+		if o.Op == op.CondOpLike {
+			return map[string]any{o.Key: regex}, nil
+		}
+		return field(o.Key, "$not", regex), nil
+
+	case op.CondOpBetween, op.CondOpNotBetween:
+		b, ok := o.Val.(op.Boundary)
+		if !ok {
+			return nil, fmt.Errorf("mongo: the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		}
+
+		if o.Op == op.CondOpBetween {
+			return map[string]any{o.Key: map[string]any{"$gte": b.Lower, "$lte": b.Upper}}, nil
+		}
+		return map[string]any{"$or": []any{field(o.Key, "$lt", b.Lower), field(o.Key, "$gt", b.Upper)}}, nil
+
+	case op.CondOpAnd, op.CondOpOr:
+		conds, _ := o.Val.([]op.Condition)
+		docs := make([]any, 0, len(conds))
+		for _, c := range conds {
+			if c == nil {
+				continue
+			}
+
+			doc, err := filter(c)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+
+		switch {
+		case len(docs) == 1:
+			return docs[0].(map[string]any), nil
+		case o.Op == op.CondOpAnd:
+			if len(docs) == 0 {
+				return map[string]any{}, nil
+			}
+			return map[string]any{"$and": docs}, nil
+		case len(docs) == 0:
+			return nil, fmt.Errorf("mongo: %w: empty %s", ErrUnsupported, o.Op)
+		default:
+			return map[string]any{"$or": docs}, nil
+		}
+
+	case op.CondOpNot:
+		c, _ := o.Val.(op.Condition)
+		doc, err := filter(c)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$nor": []any{doc}}, nil
+
+	default:
+		return nil, fmt.Errorf("mongo: %w: condition operation '%s'", ErrUnsupported, o.Op)
+	}
+}
+
+func field(key, op string, value any) map[string]any {
+	return map[string]any{key: map[string]any{op: value}}
+}
+
+// LikeToRegex converts the pattern of the LIKE condition to the regular expression,
+// in which "%" is converted to ".*", "_" is converted to ".", and the character
+// escaped by "\" is matched literally.
+func LikeToRegex(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern) + 8)
+	b.WriteByte('^')
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteByte('.')
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			} else {
+				b.WriteString(`\\`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteByte('$')
+	return b.String()
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// Update converts the updaters to the MongoDB update document,
+// such as {"$set": {...}, "$inc": {...}}.
+//
+// The updaters are normalized by op.NormalizeUpdaters first,
+// so the conflicting updaters on the same key returns an error.
+//
+// The updaters based on the other key, such as AddKey, and the updaters
+// without the MongoDB equivalent, such as UpdateOpSetIfNull, UpdateOpMod,
+// UpdateOpConcat, UpdateOpSetCase and UpdateOpUpsert, are not supported.
+// UpdateOpDiv is converted to $mul by the reciprocal, so only the float
+// divisors are supported. And PullIf by the composite condition, such as And,
+// on the element itself, that is, the empty key, is not supported.
+//
+// If the updaters contain any unbound op.Param, return an op.BindError.
+// And op.Expr is not supported, and op.RelTime is resolved by op.Prepare.
+func Update(ups ...op.Updater) (map[string]any, error) {
+	batch, params, hasExpr := op.Prepare(op.Batch(ups...))
+	if len(params) > 0 {
+		return nil, fmt.Errorf("mongo: %w", op.BindError{Missing: params})
+	}
+	if hasExpr {
+		return nil, fmt.Errorf("mongo: %w: expression", ErrUnsupported)
+	}
+
+	// Check the divisors before they are merged by NormalizeUpdaters.
+	var err error
+	op.Walk(batch, func(o op.Oper) bool {
+		if v := o.Op(); err == nil && v.Op == op.UpdateOpDiv {
+			_, err = reciprocal(v)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ups, err = op.NormalizeUpdaters(batch)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+
+	doc := make(map[string]any, 4)
+	for _, up := range ups {
+		o := up.Op()
+		if o.Lazy != nil {
+			o = o.Lazy(o)
+		}
+
+		if _, ok := o.Val.(op.KV); ok {
+			return nil, fmt.Errorf("mongo: %w: %s based on the other key", ErrUnsupported, o.Op)
+		}
+
+		switch o.Op {
+		case op.UpdateOpSet:
+			setField(doc, "$set", o.Key, o.Val)
+		case op.UpdateOpUnset:
+			setField(doc, "$unset", o.Key, "")
+		case op.UpdateOpInc:
+			setField(doc, "$inc", o.Key, 1)
+		case op.UpdateOpDec:
+			setField(doc, "$inc", o.Key, -1)
+		case op.UpdateOpAdd:
+			setField(doc, "$inc", o.Key, o.Val)
+		case op.UpdateOpMul:
+			setField(doc, "$mul", o.Key, o.Val)
+		case op.UpdateOpMin:
+			setField(doc, "$min", o.Key, o.Val)
+		case op.UpdateOpMax:
+			setField(doc, "$max", o.Key, o.Val)
+		case op.UpdateOpPop:
+			setField(doc, "$pop", o.Key, o.Val)
+		case op.UpdateOpPush:
+			setField(doc, "$push", o.Key, map[string]any{"$each": o.Val})
+		case op.UpdateOpAddToSet:
+			setField(doc, "$addToSet", o.Key, map[string]any{"$each": o.Val})
+
+		case op.UpdateOpSub:
+			v, err := negate(o)
+			if err != nil {
+				return nil, err
+			}
+			setField(doc, "$inc", o.Key, v)
+
+		case op.UpdateOpDiv:
+			v, err := reciprocal(o)
+			if err != nil {
+				return nil, err
+			}
+			setField(doc, "$mul", o.Key, v)
+
+		case op.UpdateOpPull:
+			switch v := o.Val.(type) {
+			case op.Condition:
+				pull, err := filter(v)
+				if err != nil {
+					return nil, err
+				}
+
+				if elem, ok := pull[""]; ok && len(pull) == 1 {
+					setField(doc, "$pull", o.Key, elem)
+				} else if hasElemKey(pull) {
+					return nil, fmt.Errorf("mongo: %w: %s by the composite condition on the element itself", ErrUnsupported, o.Op)
+				} else {
+					setField(doc, "$pull", o.Key, pull)
+				}
+
+			default:
+				setField(doc, "$pull", o.Key, map[string]any{"$in": v})
+			}
+
+		default:
+			return nil, fmt.Errorf("mongo: %w: update operation '%s'", ErrUnsupported, o.Op)
+		}
+	}
+
+	return doc, nil
+}
+
+// reciprocal returns the reciprocal of the divisor of UpdateOpDiv,
+// which must be a non-zero float, since the integer division
+// cannot be expressed by $mul.
+func reciprocal(o op.Op) (float64, error) {
+	v := reflect.ValueOf(o.Val)
+	if k := v.Kind(); (k != reflect.Float32 && k != reflect.Float64) || v.Float() == 0 {
+		return 0, fmt.Errorf("mongo: %w: %s by %T(%v)", ErrUnsupported, o.Op, o.Val, o.Val)
+	}
+	return 1 / v.Float(), nil
+}
+
+// hasElemKey reports whether the filter document refers the empty key,
+// that is, the array element itself, which is invalid in the composite
+// condition of $pull.
+func hasElemKey(doc map[string]any) bool {
+	for key, value := range doc {
+		switch key {
+		case "":
+			return true
+
+		case "$and", "$or", "$nor":
+			docs, _ := value.([]any)
+			for _, d := range docs {
+				if d, ok := d.(map[string]any); ok && hasElemKey(d) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func setField(doc map[string]any, op, key string, value any) {
+	fields, ok := doc[op].(map[string]any)
+	if !ok {
+		fields = make(map[string]any, 4)
+		doc[op] = fields
+	}
+	fields[key] = value
+}
+
+// negate returns the negation of the value of UpdateOpSub for $inc.
+//
+// If the negation does not fit in the type, such as int8(-128), it is
+// widened to int64, or float64 for math.MinInt64. The unsigned integer
+// greater than math.MaxInt64 is not supported, whose negation cannot be
+// represented exactly.
+func negate(o op.Op) (any, error) {
+	rv := reflect.ValueOf(o.Val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch i := rv.Int(); {
+		case i == math.MinInt64:
+			return -float64(i), nil
+		case rv.OverflowInt(-i):
+			return -i, nil
+		default:
+			return reflect.ValueOf(-i).Convert(rv.Type()).Interface(), nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return -int64(u), nil
+		}
+		return nil, fmt.Errorf("mongo: %w: %s by %T(%v)", ErrUnsupported, o.Op, o.Val, o.Val)
+
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(-rv.Float()).Convert(rv.Type()).Interface(), nil
+
+	default:
+		return nil, fmt.Errorf("mongo: the value of %s must be a number, but got %T", o.Op, o.Val)
+	}
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// Sort converts the sorters to the MongoDB sort document, which is ordered
+// and whose value is 1 for ascending or -1 for descending.
+func Sort(sorters ...op.Sorter) ([]op.KV, error) {
+	kvs := make([]op.KV, 0, len(sorters))
+	return appendSort(kvs, sorters)
+}
+
+func appendSort(kvs []op.KV, sorters []op.Sorter) ([]op.KV, error) {
+	var err error
+	for _, s := range sorters {
+		if s == nil {
+			continue
+		}
+
+		switch o := s.Op(); o.Op {
+		case op.SortOpOrders:
+			if kvs, err = appendSort(kvs, o.Val.([]op.Sorter)); err != nil {
+				return nil, err
+			}
+
+		case op.SortOpOrder:
+			switch o.Val {
+			case op.SortAsc:
+				kvs = append(kvs, op.KV{Key: o.Key, Val: 1})
+			case op.SortDesc:
+				kvs = append(kvs, op.KV{Key: o.Key, Val: -1})
+			default:
+				return nil, fmt.Errorf("mongo: invalid sort order '%v'", o.Val)
+			}
+
+		default:
+			return nil, fmt.Errorf("mongo: %w: sort operation '%s'", ErrUnsupported, o.Op)
+		}
+	}
+	return kvs, nil
+}
+
+// Pagination converts the pagination to the skip and limit of MongoDB.
+//
+// If p is nil, return (0, 0, nil).
+func Pagination(p op.Pagination) (skip, limit int64, err error) {
+	if p == nil {
+		return
+	}
+
+	switch v := p.Op().Val.(type) {
+	case op.PageSizer:
+		if v.Page > 1 {
+			skip = (v.Page - 1) * v.Size
+		}
+		limit = v.Size
+
+	case op.OffsetLimiter:
+		skip, limit = v.Offset, v.Size
+
+	default:
+		err = fmt.Errorf("mongo: %w: pagination operation '%s'", ErrUnsupported, p.Op().Op)
+	}
+	return
+}
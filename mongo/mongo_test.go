@@ -0,0 +1,121 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/xgfone/go-op"
+)
+
+func toJSON(t *testing.T, v any, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFilter(t *testing.T) {
+	doc, err := Filter(op.And(
+		op.GtEq("age", 18),
+		op.Or(op.In("status", []string{"a", "b"}), op.Like("name", "a_%")),
+		op.IsNull("deleted_at"),
+		op.NotBetween("score", 1, 9),
+	))
+
+	expect := `{"$and":[{"age":{"$gte":18}},{"$or":[{"status":{"$in":["a","b"]}},{"name":{"$regex":"^a..*$"}}]},` +
+		`{"deleted_at":{"$eq":null}},{"$or":[{"score":{"$lt":1}},{"score":{"$gt":9}}]}]}`
+	if s := toJSON(t, doc, err); s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+
+	if _, err = Filter(op.EqualKey("a", "b")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expect ErrUnsupported, but got %v", err)
+	}
+
+	var berr op.BindError
+	if _, err = Filter(op.And(op.Equal("a", 1), op.Equal("b", op.Param("b")))); !errors.As(err, &berr) {
+		t.Errorf("expect op.BindError, but got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	doc, err := Update(op.Set("name", "a"), op.Inc("count"), op.Inc("count"), op.Sub("score", 3),
+		op.Unset("tmp"), op.Push("tags", "x"), op.Key("ids").PullIf(op.Key("").Gt(10)), op.Div("rate", 2.0))
+
+	expect := `{"$inc":{"count":2,"score":-3},"$mul":{"rate":0.5},"$pull":{"ids":{"$gt":10}},` +
+		`"$push":{"tags":{"$each":["x"]}},"$set":{"name":"a"},"$unset":{"tmp":""}}`
+	if s := toJSON(t, doc, err); s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+
+	for i, c := range []struct {
+		up     op.Updater
+		expect any
+	}{
+		{op.Sub("a", int8(3)), int8(-3)},
+		{op.Sub("a", int8(math.MinInt8)), int64(-math.MinInt8)},
+		{op.Sub("a", int32(math.MinInt32)), int64(-math.MinInt32)},
+		{op.Sub("a", int64(math.MinInt64)), -float64(math.MinInt64)},
+		{op.Sub("a", uint64(math.MaxInt64)), int64(-math.MaxInt64)},
+	} {
+		if doc, err := Update(c.up); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if v := doc["$inc"].(map[string]any)["a"]; v != c.expect {
+			t.Errorf("%d: expect %T(%v), but got %T(%v)", i, c.expect, c.expect, v, v)
+		}
+	}
+
+	for i, ups := range [][]op.Updater{
+		{op.Sub("a", uint64(math.MaxUint64))},
+		{op.Key("a").AddKey("b", 1)},
+		{op.Div("a", 2)},
+		{op.Div("a", 2.0), op.Div("a", 3)},
+		{op.PullIf("items", op.And(op.Greater("", 1), op.Less("", 5)))},
+		{op.PullIf("items", op.Or(op.Equal("qty", 0), op.Less("", 5)))},
+	} {
+		if _, err = Update(ups...); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%d: expect ErrUnsupported, but got %v", i, err)
+		}
+	}
+}
+
+func TestSortAndPagination(t *testing.T) {
+	kvs, err := Sort(op.Orders(op.Order("age", op.SortDesc), op.KeyId.OrderAsc()))
+	if s := toJSON(t, kvs, err); s != `[{"Key":"age","Val":-1},{"Key":"id","Val":1}]` {
+		t.Errorf("unexpected sort document '%s'", s)
+	}
+
+	if skip, limit, err := Pagination(op.PageSize(3, 20)); err != nil {
+		t.Error(err)
+	} else if skip != 40 || limit != 20 {
+		t.Errorf("expect skip 40 and limit 20, but got %d and %d", skip, limit)
+	}
+
+	if skip, limit, err := Pagination(op.OffsetLimit(5, 0)); err != nil {
+		t.Error(err)
+	} else if skip != 5 || limit != 0 {
+		t.Errorf("expect skip 5 and limit 0, but got %d and %d", skip, limit)
+	}
+}
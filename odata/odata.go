@@ -0,0 +1,1032 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package odata parses the OData system query options, $filter, $orderby,
+// $top and $skip, to the operations, and renders the operations to them.
+package odata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xgfone/go-op"
+	"github.com/xgfone/go-op/internal/syntax"
+)
+
+// ErrUnsupported represents that the operation has no OData equivalent.
+var ErrUnsupported = errors.New("unsupported by OData")
+
+// Error represents the error of the invalid query option.
+type Error struct {
+	Option string // Such as "$filter"
+	Pos    int    // The byte offset in the option, starting with 1, or 0 if unknown.
+	Msg    string
+}
+
+// Error implements the interface error.
+func (e Error) Error() string {
+	if e.Pos == 0 {
+		return fmt.Sprintf("invalid %s: %s", e.Option, e.Msg)
+	}
+	return fmt.Sprintf("invalid %s at position %d: %s", e.Option, e.Pos, e.Msg)
+}
+
+// Query represents the system query options of OData.
+type Query struct {
+	Filter  op.Condition
+	OrderBy []op.Sorter
+	Page    op.Pagination
+}
+
+// ParseQuery parses the query options $filter, $orderby, $top and $skip
+// from the url query values, which may be empty.
+func ParseQuery(values url.Values) (q Query, err error) {
+	if q.Filter, err = ParseFilter(values.Get("$filter")); err != nil {
+		return
+	}
+	if q.OrderBy, err = ParseOrderBy(values.Get("$orderby")); err != nil {
+		return
+	}
+	q.Page, err = ParsePagination(values.Get("$top"), values.Get("$skip"))
+	return
+}
+
+// Values renders the query to the url query values, which only contains
+// the non-empty query options.
+func (q Query) Values() (url.Values, error) {
+	values := make(url.Values, 4)
+	if q.Filter != nil {
+		filter, err := FormatFilter(q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("$filter", filter)
+	}
+
+	if len(q.OrderBy) > 0 {
+		orderby, err := FormatOrderBy(q.OrderBy...)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("$orderby", orderby)
+	}
+
+	top, skip, err := FormatPagination(q.Page)
+	if err != nil {
+		return nil, err
+	}
+	if top != "" {
+		values.Set("$top", top)
+	}
+	if skip != "" {
+		values.Set("$skip", skip)
+	}
+
+	return values, nil
+}
+
+// Encode is equal to q.Values() and encodes it.
+func (q Query) Encode() (string, error) {
+	values, err := q.Values()
+	if err != nil {
+		return "", err
+	}
+	return values.Encode(), nil
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// PaginationOpNone is the pagination operation of "$top=0", which requests
+// no entities, and whose value is op.OffsetLimiter with the zero Size.
+//
+// Since the zero limit of op.OffsetLimit means no limit, it is a distinct
+// operation, so the builders not supporting it reject it instead of
+// returning all the entities.
+const PaginationOpNone = "None"
+
+// ParsePagination parses $top and $skip to the pagination, which is
+// an op.OffsetLimit, or the pagination PaginationOpNone if $top is 0.
+//
+// $top and $skip must be the non-negative integers.
+// If both are empty, return (nil, nil).
+func ParsePagination(top, skip string) (op.Pagination, error) {
+	if top == "" && skip == "" {
+		return nil, nil
+	}
+
+	var offset, limit int64 = 0, -1
+	if top != "" {
+		v, err := strconv.ParseInt(top, 10, 64)
+		if err != nil || v < 0 {
+			return nil, Error{Option: "$top", Msg: "must be a non-negative integer"}
+		}
+		limit = v
+	}
+
+	if skip != "" {
+		v, err := strconv.ParseInt(skip, 10, 64)
+		if err != nil || v < 0 {
+			return nil, Error{Option: "$skip", Msg: "must be a non-negative integer"}
+		}
+		offset = v
+	}
+
+	switch limit {
+	case -1:
+		return op.OffsetLimit(offset, 0), nil
+	case 0:
+		return op.New(PaginationOpNone, "", op.OffsetLimiter{Offset: offset}).Pagination(), nil
+	default:
+		return op.OffsetLimit(offset, limit), nil
+	}
+}
+
+// FormatPagination renders the pagination to $top and $skip,
+// which are empty if not set.
+//
+// p may be nil, op.PageSize, op.OffsetLimit or the pagination PaginationOpNone.
+func FormatPagination(p op.Pagination) (top, skip string, err error) {
+	if p == nil {
+		return
+	}
+
+	if o := p.Op(); o.Op == PaginationOpNone {
+		v, _ := o.Val.(op.OffsetLimiter)
+		if top = "0"; v.Offset > 0 {
+			skip = strconv.FormatInt(v.Offset, 10)
+		}
+		return
+	}
+
+	var offset, limit int64
+	switch v := p.Op().Val.(type) {
+	case op.PageSizer:
+		if v.Page > 1 {
+			offset = (v.Page - 1) * v.Size
+		}
+		limit = v.Size
+
+	case op.OffsetLimiter:
+		offset, limit = v.Offset, v.Size
+
+	default:
+		err = fmt.Errorf("odata: %w: pagination operation '%s'", ErrUnsupported, p.Op().Op)
+		return
+	}
+
+	if limit > 0 {
+		top = strconv.FormatInt(limit, 10)
+	}
+	if offset > 0 {
+		skip = strconv.FormatInt(offset, 10)
+	}
+	return
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// ParseOrderBy parses $orderby to the sorters, which is the comma-separated
+// properties and each property may be followed by "asc" or "desc", such as
+//
+//	Name desc, Address/City
+//
+// The path separator "/" of the property is converted to op.Sep,
+// such as "Address/City" to "Address.City".
+//
+// If orderby is empty, return (nil, nil).
+func ParseOrderBy(orderby string) ([]op.Sorter, error) {
+	return syntax.OrderBy{
+		Name:   "property",
+		Errorf: orderbyErrorf,
+		Key: func(path string, pos int) (string, error) {
+			if !isPath(path) {
+				return "", orderbyErrorf(pos, "invalid property '%s'", path)
+			}
+			return pathToKey(path), nil
+		},
+	}.Parse(orderby)
+}
+
+func orderbyErrorf(pos int, format string, args ...any) error {
+	return Error{Option: "$orderby", Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// FormatOrderBy renders the sorters to $orderby, such as "Name desc,Address/City".
+func FormatOrderBy(sorters ...op.Sorter) (string, error) {
+	var b strings.Builder
+	if err := formatOrderBy(&b, sorters); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func formatOrderBy(b *strings.Builder, sorters []op.Sorter) error {
+	for _, s := range sorters {
+		if s == nil {
+			continue
+		}
+
+		switch o := s.Op(); o.Op {
+		case op.SortOpOrders:
+			if err := formatOrderBy(b, o.Val.([]op.Sorter)); err != nil {
+				return err
+			}
+
+		case op.SortOpOrder:
+			if b.Len() > 0 {
+				b.WriteByte(',')
+			}
+
+			b.WriteString(keyToPath(o.Key))
+			switch o.Val {
+			case op.SortAsc:
+			case op.SortDesc:
+				b.WriteString(" desc")
+			default:
+				return fmt.Errorf("odata: invalid sort order '%v'", o.Val)
+			}
+
+		default:
+			return fmt.Errorf("odata: %w: sort operation '%s'", ErrUnsupported, o.Op)
+		}
+	}
+	return nil
+}
+
+func isPath(s string) bool {
+	for _, name := range strings.Split(s, "/") {
+		if name == "" {
+			return false
+		}
+
+		for i, r := range name {
+			if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func pathToKey(path string) string { return strings.ReplaceAll(path, "/", op.Sep) }
+func keyToPath(key string) string  { return strings.ReplaceAll(key, op.Sep, "/") }
+
+/// ---------------------------------------------------------------------- ///
+
+// ParseFilter parses $filter to the condition, such as
+//
+//	Price le 100 and (startswith(Name,'Mi') or Category/Name in ('A','B')) and not (Deleted eq true)
+//
+// The supported operators are as follow, and they are case-sensitive:
+//
+//	eq, ne, gt, ge, lt, le, in, and, or, not
+//	startswith(property,'string'), endswith(property,'string'), contains(property,'string')
+//
+// The precedence is "not" > comparison > "and" > "or". The left of the comparison
+// must be a property, and the right may be a literal or another property,
+// which is converted to the key comparison, such as CondOpGreaterKey.
+// "eq null" and "ne null" are converted to IsNull and IsNotNull.
+// The string functions are converted to Like, which may be compared
+// with true or false, such as "contains(Name,'a') eq false".
+//
+// The literal is the single-quoted string, in which the single quote is doubled,
+// null, true, false, the integer as int64, the decimal as float64,
+// or the date and time, such as 2024-01-02 and 2024-01-02T03:04:05Z,
+// as time.Time.
+//
+// If filter is empty, return (nil, nil).
+func ParseFilter(filter string) (op.Condition, error) {
+	p := &filterParser{lexer: lexer{src: filter}}
+	if err := p.next(); err != nil {
+		return nil, err
+	} else if p.tok.kind == tokenEOF {
+		return nil, nil
+	}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return cond, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // The raw text, or the unquoted text for the string.
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return "'" + strings.ReplaceAll(t.text, "'", "''") + "'"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenWord && t.text == kw
+}
+
+var keywords = []string{"and", "or", "not", "eq", "ne", "gt", "ge", "lt", "le", "in", "null", "true", "false"}
+
+func isKeyword(s string) bool {
+	for _, kw := range keywords {
+		if s == kw {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '/' || c == '.' || c == ':' || c == '-' || c == '+' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	return Error{Option: "$filter", Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+
+	start := l.pos
+	if start >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	switch c := l.src[start]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+
+	case c == ',':
+		l.pos++
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+
+	case c == '\'':
+		var b strings.Builder
+		for l.pos++; l.pos < len(l.src); l.pos++ {
+			if c = l.src[l.pos]; c != '\'' {
+				b.WriteByte(c)
+			} else if l.pos+1 < len(l.src) && l.src[l.pos+1] == '\'' {
+				b.WriteByte(c)
+				l.pos++
+			} else {
+				l.pos++
+				return token{kind: tokenString, text: b.String(), pos: start}, nil
+			}
+		}
+		return token{}, l.errorf(start, "unterminated string")
+
+	case isWordByte(c):
+		for l.pos < len(l.src) && isWordByte(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenWord, text: l.src[start:l.pos], pos: start}, nil
+
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[start:])
+		return token{}, l.errorf(start, "unexpected character '%c'", r)
+	}
+}
+
+type filterParser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *filterParser) next() (err error) {
+	p.tok, err = p.lexer.next()
+	return
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return p.lexer.errorf(p.tok.pos, format, args...)
+}
+
+func (p *filterParser) expect(kind tokenKind, desc string) error {
+	if p.tok.kind != kind {
+		return p.errorf("expect %s, but got %s", desc, p.tok)
+	}
+	return p.next()
+}
+
+func (p *filterParser) parseOr() (op.Condition, error) {
+	return syntax.ParseBinary(p.parseAnd, p.keyword("or"), op.Or)
+}
+
+func (p *filterParser) parseAnd() (op.Condition, error) {
+	return syntax.ParseBinary(p.parseNot, p.keyword("and"), op.And)
+}
+
+// keyword returns a function to consume the keyword kw if it is the current token.
+func (p *filterParser) keyword(kw string) func() (bool, error) {
+	return func() (bool, error) {
+		if !p.tok.keyword(kw) {
+			return false, nil
+		}
+		return true, p.next()
+	}
+}
+
+func (p *filterParser) parseNot() (op.Condition, error) {
+	switch {
+	case p.tok.keyword("not"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return op.Not(cond), nil
+
+	case p.tok.kind == tokenLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(tokenRParen, "')'")
+
+	default:
+		return p.parsePredicate()
+	}
+}
+
+func (p *filterParser) parsePath() (string, error) {
+	if p.tok.kind != tokenWord || isKeyword(p.tok.text) || !isPath(p.tok.text) {
+		return "", p.errorf("expect a property, but got %s", p.tok)
+	}
+
+	key := pathToKey(p.tok.text)
+	return key, p.next()
+}
+
+func (p *filterParser) parsePredicate() (op.Condition, error) {
+	start := p.tok
+	key, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokenLParen {
+		return p.parseFunction(start)
+	}
+
+	operator := p.tok
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case operator.keyword("in"):
+		return p.parseIn(key)
+	case operator.keyword("eq"), operator.keyword("ne"), operator.keyword("gt"),
+		operator.keyword("ge"), operator.keyword("lt"), operator.keyword("le"):
+	default:
+		return nil, p.lexer.errorf(operator.pos, "expect a comparison operator, but got %s", operator)
+	}
+
+	if p.tok.kind == tokenWord && !isKeyword(p.tok.text) && isPath(p.tok.text) {
+		other, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+
+		switch operator.text {
+		case "eq":
+			return op.EqualKey(key, other), nil
+		case "ne":
+			return op.NotEqualKey(key, other), nil
+		case "gt":
+			return op.GreaterKey(key, other), nil
+		case "ge":
+			return op.GreaterEqualKey(key, other), nil
+		case "lt":
+			return op.LessKey(key, other), nil
+		default:
+			return op.LessEqualKey(key, other), nil
+		}
+	}
+
+	pos := p.tok.pos
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator.text {
+	case "eq":
+		if value == nil {
+			return op.IsNull(key), nil
+		}
+		return op.Equal(key, value), nil
+
+	case "ne":
+		if value == nil {
+			return op.IsNotNull(key), nil
+		}
+		return op.NotEqual(key, value), nil
+	}
+
+	if value == nil {
+		return nil, p.lexer.errorf(pos, "null cannot be compared by '%s'", operator.text)
+	}
+
+	switch operator.text {
+	case "gt":
+		return op.Greater(key, value), nil
+	case "ge":
+		return op.GreaterEqual(key, value), nil
+	case "lt":
+		return op.Less(key, value), nil
+	default:
+		return op.LessEqual(key, value), nil
+	}
+}
+
+func (p *filterParser) parseIn(key string) (op.Condition, error) {
+	if err := p.expect(tokenLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	values := []any{}
+	for p.tok.kind != tokenRParen {
+		if len(values) > 0 {
+			if err := p.expect(tokenComma, "',' or ')'"); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return op.In(key, values), p.next()
+}
+
+func (p *filterParser) parseFunction(name token) (op.Condition, error) {
+	var format string
+	switch name.text {
+	case "startswith":
+		format = "%s%%"
+	case "endswith":
+		format = "%%%s"
+	case "contains":
+		format = "%%%s%%"
+	default:
+		return nil, p.lexer.errorf(name.pos, "unsupported function '%s'", name.text)
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	key, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.expect(tokenComma, "','"); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenString {
+		return nil, p.errorf("expect a string, but got %s", p.tok)
+	}
+	pattern := fmt.Sprintf(format, syntax.EscapeLike(p.tok.text))
+
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+	if err = p.expect(tokenRParen, "')'"); err != nil {
+		return nil, err
+	}
+
+	like := true
+	if p.tok.keyword("eq") || p.tok.keyword("ne") {
+		like = p.tok.text == "eq"
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case p.tok.keyword("true"):
+		case p.tok.keyword("false"):
+			like = !like
+		default:
+			return nil, p.errorf("expect true or false, but got %s", p.tok)
+		}
+
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if like {
+		return op.Like(key, pattern), nil
+	}
+	return op.NotLike(key, pattern), nil
+}
+
+func (p *filterParser) parseLiteral() (value any, err error) {
+	switch tok := p.tok; {
+	case tok.kind == tokenString:
+		value = tok.text
+
+	case tok.keyword("null"):
+		value = nil
+
+	case tok.keyword("true"):
+		value = true
+
+	case tok.keyword("false"):
+		value = false
+
+	case tok.kind == tokenWord:
+		var ok bool
+		if value, ok = parseWord(tok.text); !ok {
+			return nil, p.errorf("invalid literal %s", tok)
+		}
+
+	default:
+		return nil, p.errorf("expect a literal, but got %s", tok)
+	}
+
+	return value, p.next()
+}
+
+func parseWord(s string) (any, bool) {
+	if c := s[0]; c != '-' && c != '+' && (c < '0' || c > '9') {
+		return nil, false
+	}
+
+	if !strings.ContainsAny(s, ".eE:") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+
+	return nil, false
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// FormatFilter renders the condition to $filter.
+//
+// Like and NotLike are rendered to startswith, endswith or contains,
+// and NotLike is compared with false additionally, such as
+// "contains(Name,'a') eq false". So the pattern must be in the form
+// of "x%", "%x" or "%x%", and the wildcards in x must be escaped.
+// Between and NotBetween are rendered to the range comparisons.
+//
+// The value must be one of nil, bool, string, integers, floats or time.Time.
+func FormatFilter(cond op.Condition) (string, error) {
+	var b strings.Builder
+	if err := formatCondition(&b, cond); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var operators = map[string]string{
+	op.CondOpEqual:           "eq",
+	op.CondOpNotEqual:        "ne",
+	op.CondOpLess:            "lt",
+	op.CondOpLessEqual:       "le",
+	op.CondOpGreater:         "gt",
+	op.CondOpGreaterEqual:    "ge",
+	op.CondOpEqualKey:        "eq",
+	op.CondOpNotEqualKey:     "ne",
+	op.CondOpLessKey:         "lt",
+	op.CondOpLessEqualKey:    "le",
+	op.CondOpGreaterKey:      "gt",
+	op.CondOpGreaterEqualKey: "ge",
+}
+
+func formatCondition(b *strings.Builder, cond op.Condition) error {
+	if cond == nil {
+		return fmt.Errorf("odata: the condition is nil")
+	}
+
+	o := cond.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case op.CondOpAnd, op.CondOpOr:
+		conds, _ := o.Val.([]op.Condition)
+		if len(conds) == 0 {
+			return fmt.Errorf("odata: %w: empty %s", ErrUnsupported, o.Op)
+		}
+
+		for i, c := range conds {
+			if i > 0 {
+				b.WriteString(" " + strings.ToLower(o.Op) + " ")
+			}
+			if err := formatSubCondition(b, c, needParen(o.Op, c)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case op.CondOpNot:
+		c, _ := o.Val.(op.Condition)
+		b.WriteString("not ")
+		return formatSubCondition(b, c, needParen(o.Op, c))
+
+	case op.CondOpIsNull:
+		b.WriteString(keyToPath(o.Key) + " eq null")
+		return nil
+
+	case op.CondOpIsNotNull:
+		b.WriteString(keyToPath(o.Key) + " ne null")
+		return nil
+
+	case op.CondOpEqualKey, op.CondOpNotEqualKey, op.CondOpLessKey,
+		op.CondOpLessEqualKey, op.CondOpGreaterKey, op.CondOpGreaterEqualKey:
+		other, ok := o.Val.(string)
+		if !ok {
+			return fmt.Errorf("odata: the value of %s must be a key string, but got %T", o.Op, o.Val)
+		}
+		b.WriteString(keyToPath(o.Key) + " " + operators[o.Op] + " " + keyToPath(other))
+		return nil
+
+	case op.CondOpEqual, op.CondOpNotEqual, op.CondOpLess,
+		op.CondOpLessEqual, op.CondOpGreater, op.CondOpGreaterEqual:
+		return formatComparison(b, o.Key, operators[o.Op], o.Val)
+
+	case op.CondOpIn, op.CondOpNotIn:
+		vs := reflect.ValueOf(o.Val)
+		if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+			return fmt.Errorf("odata: the value of %s must be a slice, but got %T", o.Op, o.Val)
+		}
+
+		if o.Op == op.CondOpNotIn {
+			b.WriteString("not (")
+		}
+		b.WriteString(keyToPath(o.Key) + " in (")
+		for i, _len := 0, vs.Len(); i < _len; i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := formatValue(b, vs.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		if o.Op == op.CondOpNotIn {
+			b.WriteByte(')')
+		}
+		return nil
+
+	case op.CondOpBetween, op.CondOpNotBetween:
+		v, ok := o.Val.(op.Boundary)
+		if !ok {
+			return fmt.Errorf("odata: the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		}
+
+		lower, join, upper := "ge", " and ", "le"
+		if o.Op == op.CondOpNotBetween {
+			lower, join, upper = "lt", " or ", "gt"
+		}
+
+		b.WriteByte('(')
+		if err := formatComparison(b, o.Key, lower, v.Lower); err != nil {
+			return err
+		}
+		b.WriteString(join)
+		if err := formatComparison(b, o.Key, upper, v.Upper); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+		return nil
+
+	case op.CondOpLike, op.CondOpNotLike:
+		pattern, ok := o.Val.(string)
+		if !ok {
+			return fmt.Errorf("odata: the value of %s must be a string, but got %T", o.Op, o.Val)
+		}
+
+		function, value, ok := likeToFunction(pattern)
+		if !ok {
+			return fmt.Errorf("odata: %w: like pattern '%s'", ErrUnsupported, pattern)
+		}
+
+		b.WriteString(function + "(" + keyToPath(o.Key) + ",")
+		formatString(b, value)
+		b.WriteByte(')')
+		if o.Op == op.CondOpNotLike {
+			b.WriteString(" eq false")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("odata: %w: condition operation '%s'", ErrUnsupported, o.Op)
+	}
+}
+
+// needParen reports whether the sub-condition needs the parentheses.
+// Besides syntax.NeedParen, the operand of "not" needs them unless it is
+// self-delimited, since "not" binds tighter than the comparisons.
+func needParen(parent string, c op.Condition) bool {
+	if c == nil || syntax.NeedParen(parent, c) {
+		return c != nil
+	}
+
+	switch c.Op().Op {
+	case op.CondOpAnd, op.CondOpOr, op.CondOpNot, op.CondOpNotIn, op.CondOpBetween, op.CondOpNotBetween:
+		return false
+	default:
+		return parent == op.CondOpNot
+	}
+}
+
+func formatSubCondition(b *strings.Builder, c op.Condition, paren bool) error {
+	if paren {
+		b.WriteByte('(')
+	}
+	if err := formatCondition(b, c); err != nil {
+		return err
+	}
+	if paren {
+		b.WriteByte(')')
+	}
+	return nil
+}
+
+func formatComparison(b *strings.Builder, key, operator string, value any) error {
+	b.WriteString(keyToPath(key) + " " + operator + " ")
+	if value == nil && operator != "eq" && operator != "ne" {
+		return fmt.Errorf("odata: null cannot be compared by '%s'", operator)
+	}
+	return formatValue(b, value)
+}
+
+// likeToFunction converts the LIKE pattern to the string function,
+// and returns false if the pattern cannot be converted.
+func likeToFunction(pattern string) (function, value string, ok bool) {
+	prefix := strings.HasPrefix(pattern, "%")
+	if prefix {
+		pattern = pattern[1:]
+	}
+
+	var suffix bool
+	var b strings.Builder
+	b.Grow(len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			if i != len(pattern)-1 {
+				return
+			}
+			suffix = true
+
+		case '_':
+			return
+
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			b.WriteByte(c)
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	switch {
+	case prefix && suffix:
+		return "contains", b.String(), true
+	case prefix:
+		return "endswith", b.String(), true
+	case suffix:
+		return "startswith", b.String(), true
+	default:
+		return
+	}
+}
+
+func formatValue(b *strings.Builder, value any) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		formatString(b, v)
+	case time.Time:
+		b.WriteString(v.Format(time.RFC3339Nano))
+
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return fmt.Errorf("odata: unsupported float value %v", f)
+			}
+
+			s := strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())
+			if !strings.ContainsAny(s, ".e") {
+				s += ".0"
+			}
+			b.WriteString(s)
+
+		case reflect.String:
+			formatString(b, rv.String())
+
+		case reflect.Bool:
+			b.WriteString(strconv.FormatBool(rv.Bool()))
+
+		default:
+			return fmt.Errorf("odata: unsupported value type %T", value)
+		}
+	}
+	return nil
+}
+
+func formatString(b *strings.Builder, s string) {
+	b.WriteByte('\'')
+	b.WriteString(strings.ReplaceAll(s, "'", "''"))
+	b.WriteByte('\'')
+}
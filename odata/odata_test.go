@@ -0,0 +1,168 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package odata
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xgfone/go-op"
+)
+
+func TestParseFilter(t *testing.T) {
+	cond, err := ParseFilter(`Price le 100.5 and (startswith(Name,'Mi''s') or Category/Name in ('A','B'))` +
+		` and not (Deleted eq true) and Owner ne null and Created ge 2024-01-02T03:04:05Z` +
+		` and contains(Tag,'50%') eq false and Cost lt Price`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := op.And(
+		op.LessEqual("Price", 100.5),
+		op.Or(op.Like("Name", "Mi's%"), op.In("Category.Name", []any{"A", "B"})),
+		op.Not(op.Equal("Deleted", true)),
+		op.IsNotNull("Owner"),
+		op.GreaterEqual("Created", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		op.NotLike("Tag", `%50\%%`),
+		op.LessKey("Cost", "Price"),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	s, err := FormatFilter(cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const formatted = `Price le 100.5 and (startswith(Name,'Mi''s') or Category/Name in ('A','B'))` +
+		` and not (Deleted eq true) and Owner ne null and Created ge 2024-01-02T03:04:05Z` +
+		` and contains(Tag,'50%') eq false and Cost lt Price`
+	if s != formatted {
+		t.Errorf("expect '%s', but got '%s'", formatted, s)
+	}
+
+	if cond, err = ParseFilter(s); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	for filter, pos := range map[string]int{
+		`Price le`:                 9,
+		`Price lte 1`:              7,
+		`Price gt null`:            10,
+		`(Price eq 1`:              12,
+		`substringof('a',Name)`:    1,
+		`startswith(Name,1)`:       17,
+		`Name eq 'a`:               9,
+		`Price eq 1 Name eq 2`:     12,
+		`Price in (1,2`:            14,
+		`Price eq 1x`:              10,
+		`contains(Name,'a') eq 1`:  23,
+		`'a' eq Name`:              1,
+		`Price eq 1 and`:           15,
+		`Price eq 1 & Name eq 'a'`: 12,
+	} {
+		_, err := ParseFilter(filter)
+		var e Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: expect an Error, but got %v", filter, err)
+		} else if e.Option != "$filter" || e.Pos != pos {
+			t.Errorf("%s: expect the position %d, but got %d: %s", filter, pos, e.Pos, e.Msg)
+		}
+	}
+}
+
+func TestFormatFilter(t *testing.T) {
+	for _, c := range []struct {
+		cond   op.Condition
+		expect string
+	}{
+		{op.Between("Age", 1, 2), "(Age ge 1 and Age le 2)"},
+		{op.Not(op.NotBetween("Age", 1, 2)), "not (Age lt 1 or Age gt 2)"},
+		{op.NotIn("Age", []int{1, 2}), "not (Age in (1,2))"},
+		{op.Like("Name", `%a\_b`), "endswith(Name,'a_b')"},
+		{op.Or(op.And(op.Eq("a.b", 1), op.Eq("c", 2.0)), op.IsNull("d")), "a/b eq 1 and c eq 2.0 or d eq null"},
+		{op.And(op.Or(op.Eq("a", 1), op.Eq("c", 2))), "(a eq 1 or c eq 2)"},
+	} {
+		if s, err := FormatFilter(c.cond); err != nil {
+			t.Error(err)
+		} else if s != c.expect {
+			t.Errorf("expect '%s', but got '%s'", c.expect, s)
+		}
+	}
+
+	for _, cond := range []op.Condition{op.Like("a", "a%b"), op.Like("a", "a_"), op.Eq("a", []int{1})} {
+		if s, err := FormatFilter(cond); err == nil {
+			t.Errorf("expect an error, but got '%s'", s)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	values := url.Values{
+		"$filter":  []string{"Age gt 18"},
+		"$orderby": []string{"Name desc, Address/City"},
+		"$top":     []string{"10"},
+		"$skip":    []string{"20"},
+	}
+
+	q, err := ParseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := Query{
+		Filter:  op.Greater("Age", int64(18)),
+		OrderBy: []op.Sorter{op.Order("Name", op.SortDesc), op.Order("Address.City", op.SortAsc)},
+		Page:    op.OffsetLimit(20, 10),
+	}
+	if !reflect.DeepEqual(q, expect) {
+		t.Errorf("expect %v, but got %v", expect, q)
+	}
+
+	if s, err := q.Encode(); err != nil {
+		t.Error(err)
+	} else if expect := "%24filter=Age+gt+18&%24orderby=Name+desc%2CAddress%2FCity&%24skip=20&%24top=10"; s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+
+	page, err := ParsePagination("0", "5")
+	if err != nil {
+		t.Fatal(err)
+	} else if o := page.Op(); o.Op != PaginationOpNone || o.Val != (op.OffsetLimiter{Offset: 5}) {
+		t.Errorf("expect the pagination None with offset 5, but got %v", page)
+	}
+
+	if top, skip, err := FormatPagination(page); err != nil {
+		t.Error(err)
+	} else if top != "0" || skip != "5" {
+		t.Errorf("expect $top=0 and $skip=5, but got $top=%s and $skip=%s", top, skip)
+	}
+
+	if _, err := ParseQuery(url.Values{"$top": []string{"-1"}}); err == nil || err.Error() != "invalid $top: must be a non-negative integer" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ParseOrderBy("Name up"); err == nil || err.Error() != "invalid $orderby at position 6: expect 'asc' or 'desc', but got 'up'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -85,9 +85,17 @@ func (o Op) Prefix(prefix string) Op { return o.KeyPrefix(prefix) }
 func (o Op) Suffix(suffix string) Op { return o.KeySuffix(suffix) }
 
 // Scope is equal to o.Prefix(name + Sep).
+//
+// If the value contains the other keys, such as GeoRadius and the columns
+// of Expr, they are also scoped. The empty key of CondOpExpr is kept,
+// since its keys are all in the expression.
 func (o Op) Scope(name string) Op {
+	if s, ok := o.Val.(keyScoper); ok && len(name) > 0 {
+		o.Val = s.scopeKeys(name)
+	}
+
 	switch {
-	case len(name) == 0:
+	case len(name) == 0, o.Op == CondOpExpr:
 		return o
 
 	case len(o.Key) == 0:
@@ -99,6 +107,19 @@ func (o Op) Scope(name string) Op {
 	}
 }
 
+// keyScoper is implemented by the value containing the other keys,
+// which are scoped together with the key of the operation.
+type keyScoper interface {
+	scopeKeys(name string) any
+}
+
+func scopeKey(name, key string) string {
+	if len(key) == 0 {
+		return name
+	}
+	return strings.Join([]string{name, key}, Sep)
+}
+
 // KeyPrefix returns a new Op, which uses prefix as the prefix of the key.
 func (o Op) KeyPrefix(prefix string) Op {
 	o.Key = prefix + o.Key
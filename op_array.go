@@ -0,0 +1,170 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import "reflect"
+
+// Pre-define some array update operations.
+//
+// When several array operations target the same key in UpdateOpBatch,
+// they are applied in order, that's, the latter is applied to the array
+// updated by the former. MergeArrayUpdaters may be used to merge them.
+const (
+	UpdateOpPush     = "Push"     // Append the elements to the end of the array.
+	UpdateOpPull     = "Pull"     // Remove all the elements matching the values or condition.
+	UpdateOpAddToSet = "AddToSet" // Append the elements only if they do not exist in the array.
+	UpdateOpPop      = "Pop"      // Remove the first element if value is -1, or the last if 1.
+)
+
+// Push is equal to Key(key).Push(values...).
+func Push(key string, values ...any) Updater {
+	return Key(key).Push(values...)
+}
+
+// Pull is equal to Key(key).Pull(values...).
+func Pull(key string, values ...any) Updater {
+	return Key(key).Pull(values...)
+}
+
+// PullIf is equal to Key(key).PullIf(cond).
+func PullIf(key string, cond Condition) Updater {
+	return Key(key).PullIf(cond)
+}
+
+// AddToSet is equal to Key(key).AddToSet(values...).
+func AddToSet(key string, values ...any) Updater {
+	return Key(key).AddToSet(values...)
+}
+
+// PopFirst is equal to Key(key).PopFirst().
+func PopFirst(key string) Updater {
+	return Key(key).PopFirst()
+}
+
+// PopLast is equal to Key(key).PopLast().
+func PopLast(key string) Updater {
+	return Key(key).PopLast()
+}
+
+// Push is equal to o.WithOp(UpdateOpPush).WithValue(values).Updater().
+func (o Op) Push(values ...any) Updater {
+	return o.WithOp(UpdateOpPush).WithValue(values).Updater()
+}
+
+// Pull is equal to o.WithOp(UpdateOpPull).WithValue(values).Updater().
+func (o Op) Pull(values ...any) Updater {
+	return o.WithOp(UpdateOpPull).WithValue(values).Updater()
+}
+
+// PullIf is equal to o.WithOp(UpdateOpPull).WithValue(cond).Updater().
+//
+// The key of the condition is relative to the element of the array.
+// For the array of the scalar values, the key should be empty.
+func (o Op) PullIf(cond Condition) Updater {
+	return o.WithOp(UpdateOpPull).WithValue(cond).Updater()
+}
+
+// AddToSet is equal to o.WithOp(UpdateOpAddToSet).WithValue(values).Updater().
+func (o Op) AddToSet(values ...any) Updater {
+	return o.WithOp(UpdateOpAddToSet).WithValue(values).Updater()
+}
+
+// PopFirst is equal to o.WithOp(UpdateOpPop).WithValue(-1).Updater().
+func (o Op) PopFirst() Updater {
+	return o.WithOp(UpdateOpPop).WithValue(-1).Updater()
+}
+
+// PopLast is equal to o.WithOp(UpdateOpPop).WithValue(1).Updater().
+func (o Op) PopLast() Updater {
+	return o.WithOp(UpdateOpPop).WithValue(1).Updater()
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// MergeArrayUpdaters flattens the batch updaters, and merges the adjacent
+// array updaters with the same operation on the same key, which are not
+// separated by other updaters on the same key. For example,
+//
+//	Push(k, a), Set(x, 1), Push(k, b)  =>  Push(k, a, b), Set(x, 1)
+//	AddToSet(k, a, b), AddToSet(k, b)  =>  AddToSet(k, a, b)
+//	Pull(k, a), Pull(k, b)             =>  Pull(k, a, b)
+//	PullIf(k, c1), PullIf(k, c2)       =>  PullIf(k, Or(c1, c2))
+//	Push(k, a), Pull(k, a), Push(k, b) =>  (unchanged)
+//
+// UpdateOpPop is never merged.
+func MergeArrayUpdaters(ups ...Updater) []Updater {
+	ups = flattenUpdaters(ups)
+	merged := make([]Updater, 0, len(ups))
+	lasts := make(map[string]int, len(ups))
+	for _, up := range ups {
+		op := up.Op()
+		if index, ok := lasts[op.Key]; ok {
+			if last := merged[index].Op(); last.Op == op.Op {
+				if value, ok := mergeArrayValues(op.Op, last.Val, op.Val); ok {
+					merged[index] = last.WithValue(value).Updater()
+					continue
+				}
+			}
+		}
+
+		lasts[op.Key] = len(merged)
+		merged = append(merged, up)
+	}
+	return merged
+}
+
+func mergeArrayValues(op string, v1, v2 any) (any, bool) {
+	switch op {
+	case UpdateOpPush:
+		vs1, ok1 := v1.([]any)
+		vs2, ok2 := v2.([]any)
+		if ok1 && ok2 {
+			return append(append(make([]any, 0, len(vs1)+len(vs2)), vs1...), vs2...), true
+		}
+
+	case UpdateOpAddToSet, UpdateOpPull:
+		vs1, ok1 := v1.([]any)
+		vs2, ok2 := v2.([]any)
+		if ok1 && ok2 {
+			return appendUnique(append(make([]any, 0, len(vs1)+len(vs2)), vs1...), vs2...), true
+		}
+
+		c1, ok1 := v1.(Condition)
+		c2, ok2 := v2.(Condition)
+		if ok1 && ok2 && op == UpdateOpPull {
+			return Or(c1, c2), true
+		}
+	}
+
+	return nil, false
+}
+
+func appendUnique(values []any, news ...any) []any {
+	for _, v := range news {
+		if !containsValue(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
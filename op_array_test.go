@@ -0,0 +1,56 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeArrayUpdaters(t *testing.T) {
+	for i, c := range []struct {
+		ups     []Updater
+		expects []Updater
+	}{
+		{
+			[]Updater{Push("a", 1), PopFirst("a"), Push("a", 2)},
+			[]Updater{Push("a", 1), PopFirst("a"), Push("a", 2)},
+		},
+		{
+			[]Updater{PopLast("a"), Push("a", 1), Push("a", 2)},
+			[]Updater{PopLast("a"), Push("a", 1, 2)},
+		},
+		{
+			[]Updater{Push("a", 1), Push("a", 2), PopLast("a")},
+			[]Updater{Push("a", 1, 2), PopLast("a")},
+		},
+		{
+			[]Updater{PopFirst("a"), PopFirst("a")},
+			[]Updater{PopFirst("a"), PopFirst("a")},
+		},
+		{
+			[]Updater{Push("a", 1), PopFirst("b"), Push("a", 2)},
+			[]Updater{Push("a", 1, 2), PopFirst("b")},
+		},
+		{
+			[]Updater{PullIf("a", Eq("x", 1)), PullIf("a", Eq("x", 2)), Pull("a", 3)},
+			[]Updater{PullIf("a", Or(Eq("x", 1), Eq("x", 2))), Pull("a", 3)},
+		},
+	} {
+		if ups := MergeArrayUpdaters(c.ups...); !reflect.DeepEqual(ups, c.expects) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expects, ups)
+		}
+	}
+}
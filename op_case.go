@@ -0,0 +1,119 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+// UpdateOpSetCase is the conditional update operation, which is like
+//
+//	key = CASE WHEN cond1 THEN value1 WHEN cond2 THEN value2 ELSE value END
+//
+// Its value is Cases. If there is no ELSE branch, the key is left unchanged
+// when no branch is matched, that's, ELSE key.
+const UpdateOpSetCase = "SetCase"
+
+// Case represents a branch of the conditional update operation.
+type Case struct {
+	When Condition // nil represents the ELSE branch.
+	Then any
+}
+
+// When returns a WHEN branch, which uses value when cond is matched.
+//
+// If cond is nil, panic, since the nil condition represents the ELSE branch,
+// which should be built by Else instead.
+func When(cond Condition, value any) Case {
+	if cond == nil {
+		panic("op.When: the condition must not be nil")
+	}
+	return Case{When: cond, Then: value}
+}
+
+// Else returns an ELSE branch, which uses value when no WHEN branch is matched.
+func Else(value any) Case { return Case{Then: value} }
+
+// IsElse reports whether the branch is the ELSE branch.
+func (c Case) IsElse() bool { return c.When == nil }
+
+// Cases is a set of the branches, which is the value of UpdateOpSetCase.
+type Cases []Case
+
+// Whens returns all the WHEN branches in order.
+func (cs Cases) Whens() []Case {
+	whens := make([]Case, 0, len(cs))
+	for _, c := range cs {
+		if !c.IsElse() {
+			whens = append(whens, c)
+		}
+	}
+	return whens
+}
+
+// Else returns the ELSE branch. If not exist, return (Case{}, false).
+func (cs Cases) Else() (c Case, ok bool) {
+	for _, c := range cs {
+		if c.IsElse() {
+			return c, true
+		}
+	}
+	return
+}
+
+// Resolve evaluates the WHEN branches in order by match for a record,
+// and returns the value of the first matched branch, or the ELSE branch
+// if no WHEN branch is matched.
+//
+// If no branch is matched and there is no ELSE branch, return (nil, false, nil),
+// which represents that the key should be left unchanged.
+func (cs Cases) Resolve(match func(Condition) (bool, error)) (value any, ok bool, err error) {
+	for _, c := range cs {
+		if c.IsElse() {
+			continue
+		}
+
+		if ok, err = match(c.When); err != nil || ok {
+			return c.Then, ok, err
+		}
+	}
+
+	if c, ok := cs.Else(); ok {
+		return c.Then, true, nil
+	}
+	return nil, false, nil
+}
+
+// SetCase is equal to Key(key).SetCase(cases...).
+func SetCase(key string, cases ...Case) Updater {
+	return Key(key).SetCase(cases...)
+}
+
+// SetCase is equal to o.WithOp(UpdateOpSetCase).WithValue(Cases(cases)).Updater().
+//
+// For example,
+//
+//	Key("status").SetCase(When(Less("end_at", now), "expired"), Else("active"))
+func (o Op) SetCase(cases ...Case) Updater {
+	return o.WithOp(UpdateOpSetCase).WithValue(Cases(cases)).Updater()
+}
+
+// Opers implements the interface Composite,
+// which returns the conditions of the WHEN branches.
+func (cs Cases) Opers() []Oper {
+	opers := make([]Oper, 0, len(cs))
+	for _, c := range cs {
+		if !c.IsElse() {
+			opers = append(opers, c.When)
+		}
+	}
+	return opers
+}
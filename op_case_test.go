@@ -0,0 +1,65 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCasesResolve(t *testing.T) {
+	match := func(c Condition) (bool, error) {
+		switch c.Op().Key {
+		case "yes":
+			return true, nil
+		case "err":
+			return false, errors.New("error")
+		default:
+			return false, nil
+		}
+	}
+
+	for i, c := range []struct {
+		cases Cases
+		value any
+		ok    bool
+		err   bool
+	}{
+		{Cases{When(IsNull("no"), 1), When(IsNull("yes"), 2), Else(3)}, 2, true, false},
+		{Cases{When(IsNull("no"), 1), Else(3)}, 3, true, false},
+		{Cases{Else(3), When(IsNull("yes"), 2)}, 2, true, false},
+		{Cases{When(IsNull("no"), 1)}, nil, false, false},
+		{Cases{Else(nil)}, nil, true, false},
+		{Cases{}, nil, false, false},
+		{Cases{When(IsNull("err"), 1), When(IsNull("yes"), 2)}, 1, false, true},
+	} {
+		value, ok, err := c.cases.Resolve(match)
+		if (err != nil) != c.err {
+			t.Errorf("%d: expect error=%v, but got %v", i, c.err, err)
+		} else if ok != c.ok || !reflect.DeepEqual(value, c.value) {
+			t.Errorf("%d: expect (%v, %v), but got (%v, %v)", i, c.value, c.ok, value, ok)
+		}
+	}
+}
+
+func TestWhenNil(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect a panic, but got nil")
+		}
+	}()
+	When(nil, 1)
+}
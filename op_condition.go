@@ -47,9 +47,13 @@ const (
 	CondOpGreaterKey      = "GreaterKey"
 	CondOpGreaterEqualKey = "GreaterEqualKey"
 
+	// The value is the boolean expression, such as Col("price").Mul(Col("qty")).Greater(100).
+	CondOpExpr = "Expr"
+
 	// The composite conditions.
 	CondOpAnd = "And"
 	CondOpOr  = "Or"
+	CondOpNot = "Not"
 )
 
 // Boundary is used by the BETWEEN condition.
@@ -157,6 +161,11 @@ func Or(ops ...Condition) Condition {
 	return New(CondOpOr, "", ops).Condition()
 }
 
+// Not is equal to New(CondOpNot, "", cond).Condition().
+func Not(cond Condition) Condition {
+	return New(CondOpNot, "", cond).Condition()
+}
+
 // Eq is short for Equal.
 func Eq(key string, value any) Condition { return Equal(key, value) }
 
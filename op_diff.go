@@ -0,0 +1,333 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xgfone/go-op/internal/strcase"
+)
+
+// DefaultTag is the default name of the struct tag to get the key of the field.
+const DefaultTag = "sql"
+
+// DiffOptions is the options of Diff.
+type DiffOptions struct {
+	// Tag is the name of the struct tag to get the key of the field.
+	//
+	// If empty, use DefaultTag instead. If the field has no tag,
+	// use the snake case of the field name. If the tag is "-",
+	// the field is ignored.
+	Tag string
+
+	// Ignores is the list of the keys to be ignored, such as KeyUpdatedAt.Key.
+	//
+	// For the nested struct, the key is scoped, such as "addr.city".
+	Ignores []string
+
+	// If true, use Add or Sub instead of Set for the changed numeric values.
+	Delta bool
+}
+
+func (o *DiffOptions) ignored(key string) bool {
+	for _, k := range o.Ignores {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares the old and new values field by field, and returns the updaters
+// only for the changed fields, which are ordered by the struct fields or the map keys.
+//
+// old and new must be the structs or maps with the string key of the same type,
+// or the pointers to them. The nested struct or map is compared recursively,
+// whose keys are scoped by the parent key, such as "addr.city". But the embedded
+// struct without the tag is flattened. The struct without any exported fields,
+// such as time.Time, is compared as a whole.
+//
+// For the key existing in the old map but not in the new map, return Unset(key).
+//
+// If old or new is nil, their types are different, or the type is not
+// a struct or map, return an error.
+//
+// opts may be nil, which is equal to &DiffOptions{}.
+func Diff(old, new any, opts *DiffOptions) ([]Updater, error) {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	if opts.Tag == "" {
+		_opts := *opts
+		_opts.Tag = DefaultTag
+		opts = &_opts
+	}
+
+	ov, nv := indirect(reflect.ValueOf(old)), indirect(reflect.ValueOf(new))
+	if !ov.IsValid() || !nv.IsValid() {
+		return nil, fmt.Errorf("op.Diff: old or new must not be nil")
+	} else if ov.Type() != nv.Type() {
+		return nil, fmt.Errorf("op.Diff: the type is inconsistent, %s != %s", ov.Type(), nv.Type())
+	}
+
+	switch ov.Kind() {
+	case reflect.Struct, reflect.Map:
+	default:
+		return nil, fmt.Errorf("op.Diff: unsupported type %s", ov.Type())
+	}
+
+	return diffValue(nil, opts, "", ov, nv), nil
+}
+
+func diffValue(ups []Updater, opts *DiffOptions, key string, ov, nv reflect.Value) []Updater {
+	if opts.ignored(key) {
+		return ups
+	}
+
+	if ov.Kind() == reflect.Interface && nv.Kind() == reflect.Interface {
+		ov, nv = ov.Elem(), nv.Elem()
+		if !ov.IsValid() || !nv.IsValid() || ov.Type() != nv.Type() {
+			return diffLeaf(ups, opts, key, ov, nv)
+		}
+	}
+
+	switch ov.Kind() {
+	case reflect.Pointer:
+		if ov.IsNil() || nv.IsNil() {
+			return diffLeaf(ups, opts, key, ov, nv)
+		}
+		return diffValue(ups, opts, key, ov.Elem(), nv.Elem())
+
+	case reflect.Struct:
+		if !hasExportedField(ov.Type()) {
+			return diffLeaf(ups, opts, key, ov, nv)
+		}
+		return diffStruct(ups, opts, key, ov, nv)
+
+	case reflect.Map:
+		if ov.Type().Key().Kind() != reflect.String || ov.IsNil() || nv.IsNil() {
+			return diffLeaf(ups, opts, key, ov, nv)
+		}
+		return diffMap(ups, opts, key, ov, nv)
+
+	default:
+		return diffLeaf(ups, opts, key, ov, nv)
+	}
+}
+
+func diffStruct(ups []Updater, opts *DiffOptions, scope string, ov, nv reflect.Value) []Updater {
+	vtype := ov.Type()
+	for i, _len := 0, vtype.NumField(); i < _len; i++ {
+		sf := vtype.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, ok := getFieldKey(sf, opts.Tag)
+		if !ok {
+			continue
+		}
+
+		if sf.Anonymous && name == "" && isStructOrPtr(sf.Type) {
+			ups = diffEmbedded(ups, opts, scope, ov.Field(i), nv.Field(i))
+		} else {
+			if name == "" {
+				name = strcase.Snake(sf.Name)
+			}
+			ups = diffValue(ups, opts, Key(name).Scope(scope).Key, ov.Field(i), nv.Field(i))
+		}
+	}
+	return ups
+}
+
+// diffEmbedded diffs the fields of the untagged embedded struct
+// in the scope of the parent. If the embedded pointer is nil on one side,
+// its fields are diffed against the zero value.
+func diffEmbedded(ups []Updater, opts *DiffOptions, scope string, ov, nv reflect.Value) []Updater {
+	if ov.Kind() == reflect.Pointer {
+		if ov.IsNil() && nv.IsNil() {
+			return ups
+		}
+
+		zero := reflect.New(ov.Type().Elem()).Elem()
+		if ov = ov.Elem(); !ov.IsValid() {
+			ov = zero
+		}
+		if nv = nv.Elem(); !nv.IsValid() {
+			nv = zero
+		}
+	}
+	return diffStruct(ups, opts, scope, ov, nv)
+}
+
+func diffMap(ups []Updater, opts *DiffOptions, scope string, ov, nv reflect.Value) []Updater {
+	keys := make([]string, 0, nv.Len())
+	for _, k := range nv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	for _, k := range ov.MapKeys() {
+		if !nv.MapIndex(k).IsValid() {
+			keys = append(keys, k.String())
+		}
+	}
+	sort.Strings(keys)
+
+	ktype := ov.Type().Key()
+	for _, k := range keys {
+		key := Key(k).Scope(scope).Key
+		mk := reflect.ValueOf(k).Convert(ktype)
+		ovalue, nvalue := ov.MapIndex(mk), nv.MapIndex(mk)
+		switch {
+		case !ovalue.IsValid():
+			ups = diffLeaf(ups, opts, key, ovalue, nvalue)
+		case !nvalue.IsValid():
+			if !opts.ignored(key) {
+				ups = append(ups, Unset(key))
+			}
+		default:
+			ups = diffValue(ups, opts, key, ovalue, nvalue)
+		}
+	}
+	return ups
+}
+
+func diffLeaf(ups []Updater, opts *DiffOptions, key string, ov, nv reflect.Value) []Updater {
+	if opts.ignored(key) || equalValue(ov, nv) {
+		return ups
+	}
+
+	if !nv.IsValid() {
+		return append(ups, Set(key, nil))
+	}
+
+	if opts.Delta && ov.IsValid() && ov.Type() == nv.Type() {
+		var delta reflect.Value
+		var sub bool
+		switch nv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// The difference of two int64 always fits in uint64.
+			var d uint64
+			if o, n := ov.Int(), nv.Int(); n < o {
+				d, sub = uint64(o)-uint64(n), true
+			} else {
+				d = uint64(n) - uint64(o)
+			}
+
+			if d <= math.MaxInt64 && !reflect.Zero(nv.Type()).OverflowInt(int64(d)) {
+				delta = reflect.ValueOf(int64(d))
+			}
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			var d uint64
+			if o, n := ov.Uint(), nv.Uint(); n < o {
+				d, sub = o-n, true
+			} else {
+				d = n - o
+			}
+
+			if !reflect.Zero(nv.Type()).OverflowUint(d) {
+				delta = reflect.ValueOf(d)
+			}
+
+		case reflect.Float32, reflect.Float64:
+			var d float64
+			if o, n := ov.Float(), nv.Float(); n < o {
+				d, sub = o-n, true
+			} else {
+				d = n - o
+			}
+
+			if !math.IsInf(d, 0) && !reflect.Zero(nv.Type()).OverflowFloat(d) {
+				delta = reflect.ValueOf(d)
+			}
+		}
+
+		// If the delta does not fit in the type, such as 200 for int8,
+		// fall back to Set.
+		if delta.IsValid() {
+			value := delta.Convert(nv.Type()).Interface()
+			if sub {
+				return append(ups, Sub(key, value))
+			}
+			return append(ups, Add(key, value))
+		}
+	}
+
+	return append(ups, Set(key, valueInterface(nv)))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func equalValue(ov, nv reflect.Value) bool {
+	switch {
+	case !ov.IsValid() || !nv.IsValid():
+		return ov.IsValid() == nv.IsValid()
+
+	case ov.Type() != nv.Type():
+		return false
+
+	case ov.Type() == timeType:
+		return ov.Interface().(time.Time).Equal(nv.Interface().(time.Time))
+
+	default:
+		return reflect.DeepEqual(ov.Interface(), nv.Interface())
+	}
+}
+
+func valueInterface(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v
+}
+
+func isStructOrPtr(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func hasExportedField(t reflect.Type) bool {
+	for i, _len := 0, t.NumField(); i < _len; i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldKey returns the key of the struct field from the tag.
+//
+// If the tag is "-", return ("", false).
+func getFieldKey(sf reflect.StructField, tag string) (key string, ok bool) {
+	key, _, _ = strings.Cut(sf.Tag.Get(tag), ",")
+	return key, key != "-"
+}
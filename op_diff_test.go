@@ -0,0 +1,148 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	type Addr struct {
+		City string `sql:"city"`
+		Zip  *string
+	}
+
+	type Base struct {
+		ID        int64     `sql:"id"`
+		UpdatedAt time.Time `sql:"updated_at"`
+	}
+
+	type User struct {
+		Base
+		Name    string `sql:"name"`
+		Age     int
+		Score   float64
+		Addr    Addr   `sql:"addr"`
+		Ignored string `sql:"-"`
+	}
+
+	zip := "100000"
+	now := time.Now()
+	old := User{Base: Base{ID: 1, UpdatedAt: now}, Name: "a", Age: 18, Score: 9.5, Addr: Addr{City: "Beijing"}}
+	new := User{Base: Base{ID: 1, UpdatedAt: now.Add(time.Second)}, Name: "b", Age: 16, Score: 9.5,
+		Addr: Addr{City: "Beijing", Zip: &zip}, Ignored: "x"}
+
+	ups, err := Diff(old, &new, &DiffOptions{Ignores: []string{KeyUpdatedAt.Key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []Updater{Set("name", "b"), Set("age", 16), Set("addr.zip", "100000")}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	if ups, err = Diff(old, new, &DiffOptions{Delta: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expects = []Updater{Set("updated_at", new.UpdatedAt), Set("name", "b"), Sub("age", 2), Set("addr.zip", "100000")}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	ups, err = Diff(map[string]any{"a": 1, "b": map[string]any{"c": 2}, "d": 3},
+		map[string]any{"a": 1, "b": map[string]any{"c": 3}, "e": 4}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects = []Updater{Set("b.c", 3), Unset("d"), Set("e", 4)}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	for i, c := range [][2]any{
+		{nil, new},
+		{old, nil},
+		{(*User)(nil), &new},
+		{old, map[string]any{}},
+		{1, 2},
+	} {
+		if ups, err := Diff(c[0], c[1], nil); err == nil {
+			t.Errorf("%d: expect an error, but got %v", i, ups)
+		}
+	}
+}
+
+func TestDiffDelta(t *testing.T) {
+	type Value struct {
+		I8  int8
+		U8  uint8
+		I64 int64
+		U64 uint64
+	}
+
+	opts := &DiffOptions{Delta: true}
+	for i, c := range []struct {
+		old, new Value
+		expects  []Updater
+	}{
+		{Value{I8: -100}, Value{I8: 20}, []Updater{Add("i8", int8(120))}},
+		{Value{I8: -100}, Value{I8: 100}, []Updater{Set("i8", int8(100))}},
+		{Value{I8: 127}, Value{I8: -128}, []Updater{Set("i8", int8(-128))}},
+		{Value{U8: 255}, Value{U8: 0}, []Updater{Sub("u8", uint8(255))}},
+		{Value{I64: math.MinInt64}, Value{I64: math.MaxInt64}, []Updater{Set("i64", int64(math.MaxInt64))}},
+		{Value{I64: math.MaxInt64}, Value{I64: 0}, []Updater{Sub("i64", int64(math.MaxInt64))}},
+		{Value{I64: math.MaxInt64}, Value{I64: -1}, []Updater{Set("i64", int64(-1))}},
+		{Value{U64: math.MaxUint64}, Value{U64: 0}, []Updater{Sub("u64", uint64(math.MaxUint64))}},
+	} {
+		if ups, err := Diff(c.old, c.new, opts); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(ups, c.expects) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expects, ups)
+		}
+	}
+}
+
+func TestDiffEmbeddedPointer(t *testing.T) {
+	type Base struct {
+		ID   int64 `sql:"id"`
+		Name string
+	}
+
+	type User struct {
+		*Base
+		Age int
+	}
+
+	for i, c := range []struct {
+		old, new User
+		expects  []Updater
+	}{
+		{User{Age: 1}, User{Age: 2}, []Updater{Set("age", 2)}},
+		{User{Age: 1}, User{Base: &Base{ID: 1}, Age: 1}, []Updater{Set("id", int64(1))}},
+		{User{Base: &Base{ID: 1, Name: "a"}}, User{}, []Updater{Set("id", int64(0)), Set("name", "")}},
+	} {
+		if ups, err := Diff(c.old, c.new, nil); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(ups, c.expects) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expects, ups)
+		}
+	}
+}
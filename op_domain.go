@@ -0,0 +1,471 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// bound is the lower or upper bound of an interval.
+type bound struct {
+	value any
+	inf   bool // -inf for the lower bound, or +inf for the upper bound.
+	open  bool // Whether the value is excluded.
+}
+
+type interval struct{ lo, hi bound }
+
+var infInterval = interval{lo: bound{inf: true}, hi: bound{inf: true}}
+
+// domain is the set of the values of a key, which satisfy a condition,
+// and consists of null and the sorted, disjoint and non-empty intervals.
+type domain struct {
+	null   bool
+	ranges []interval
+}
+
+func (d domain) empty() bool { return !d.null && len(d.ranges) == 0 }
+
+var (
+	fullDomain    = domain{null: true, ranges: []interval{infInterval}}
+	nonNullDomain = domain{ranges: []interval{infInterval}}
+)
+
+// comparator is used to operate the domains, which records
+// whether two values cannot be compared during the operations.
+type comparator struct{ failed bool }
+
+func (c *comparator) compare(a, b any) int {
+	r, ok := compareValues(a, b)
+	if !ok {
+		c.failed = true
+	}
+	return r
+}
+
+// lowerLess reports whether the lower bound a is less than b.
+func (c *comparator) lowerLess(a, b bound) bool {
+	switch {
+	case b.inf:
+		return false
+	case a.inf:
+		return true
+	}
+
+	r := c.compare(a.value, b.value)
+	return r < 0 || (r == 0 && !a.open && b.open)
+}
+
+// upperLess reports whether the upper bound a is less than b.
+func (c *comparator) upperLess(a, b bound) bool {
+	switch {
+	case a.inf:
+		return false
+	case b.inf:
+		return true
+	}
+
+	r := c.compare(a.value, b.value)
+	return r < 0 || (r == 0 && a.open && !b.open)
+}
+
+// valid reports whether the interval is not empty.
+func (c *comparator) valid(i interval) bool {
+	if i.lo.inf || i.hi.inf {
+		return true
+	}
+
+	r := c.compare(i.lo.value, i.hi.value)
+	return r < 0 || (r == 0 && !i.lo.open && !i.hi.open)
+}
+
+// joinable reports whether the interval a, whose lower bound is not greater
+// than b, overlaps or touches b.
+func (c *comparator) joinable(a, b interval) bool {
+	if a.hi.inf || b.lo.inf {
+		return true
+	}
+
+	r := c.compare(a.hi.value, b.lo.value)
+	return r > 0 || (r == 0 && (!a.hi.open || !b.lo.open))
+}
+
+// normalize sorts and merges the intervals, and removes the empty ones.
+func (c *comparator) normalize(ranges []interval) []interval {
+	results := make([]interval, 0, len(ranges))
+	for _, i := range ranges {
+		if c.valid(i) {
+			results = append(results, i)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return c.lowerLess(results[i].lo, results[j].lo)
+	})
+
+	merged := results[:0]
+	for _, i := range results {
+		if n := len(merged); n > 0 && c.joinable(merged[n-1], i) {
+			if c.upperLess(merged[n-1].hi, i.hi) {
+				merged[n-1].hi = i.hi
+			}
+		} else {
+			merged = append(merged, i)
+		}
+	}
+	return merged
+}
+
+func (c *comparator) intersect(a, b domain) domain {
+	ranges := make([]interval, 0, len(a.ranges)+len(b.ranges))
+	for _, i := range a.ranges {
+		for _, j := range b.ranges {
+			r := i
+			if c.lowerLess(r.lo, j.lo) {
+				r.lo = j.lo
+			}
+			if c.upperLess(j.hi, r.hi) {
+				r.hi = j.hi
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return domain{null: a.null && b.null, ranges: c.normalize(ranges)}
+}
+
+func (c *comparator) union(a, b domain) domain {
+	ranges := make([]interval, 0, len(a.ranges)+len(b.ranges))
+	ranges = append(ranges, a.ranges...)
+	ranges = append(ranges, b.ranges...)
+	return domain{null: a.null || b.null, ranges: c.normalize(ranges)}
+}
+
+// subset reports whether the domain a is the subset of b.
+func (c *comparator) subset(a, b domain) bool {
+	if a.null && !b.null {
+		return false
+	}
+
+	for _, i := range a.ranges {
+		var contained bool
+		for _, j := range b.ranges {
+			if !c.lowerLess(i.lo, j.lo) && !c.upperLess(j.hi, i.hi) {
+				contained = true
+				break
+			}
+		}
+
+		if !contained {
+			return false
+		}
+	}
+	return true
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// negatedCondOps is the mapping between the condition operation
+// and its negation, which excludes null for the comparisons
+// as the three-valued logic of SQL.
+var negatedCondOps = map[string]string{
+	CondOpEqual:           CondOpNotEqual,
+	CondOpNotEqual:        CondOpEqual,
+	CondOpLess:            CondOpGreaterEqual,
+	CondOpLessEqual:       CondOpGreater,
+	CondOpGreater:         CondOpLessEqual,
+	CondOpGreaterEqual:    CondOpLess,
+	CondOpIn:              CondOpNotIn,
+	CondOpNotIn:           CondOpIn,
+	CondOpBetween:         CondOpNotBetween,
+	CondOpNotBetween:      CondOpBetween,
+	CondOpIsNull:          CondOpIsNotNull,
+	CondOpIsNotNull:       CondOpIsNull,
+	CondOpLike:            CondOpNotLike,
+	CondOpNotLike:         CondOpLike,
+	CondOpEqualKey:        CondOpNotEqualKey,
+	CondOpNotEqualKey:     CondOpEqualKey,
+	CondOpLessKey:         CondOpGreaterEqualKey,
+	CondOpLessEqualKey:    CondOpGreaterKey,
+	CondOpGreaterKey:      CondOpLessEqualKey,
+	CondOpGreaterEqualKey: CondOpLessKey,
+}
+
+// condNode is the condition tree in the negation normal form,
+// which is either And, Or, or a leaf.
+type condNode struct {
+	op    string // CondOpAnd, CondOpOr, or empty for the leaf.
+	nodes []condNode
+
+	// For the leaf. If known is false, the domain of the leaf
+	// is unknown, and it is compared by the operation atom.
+	atom    Op
+	dom     domain
+	known   bool
+	negated bool // Only for the unknown atom without the negated operation.
+}
+
+// newCondNode converts the condition to the tree in the negation normal form.
+//
+// nil is regarded as the always-true condition, that's, the empty And.
+func (c *comparator) newCondNode(cond Condition, negate bool) (condNode, error) {
+	if cond == nil {
+		if negate {
+			return condNode{op: CondOpOr}, nil
+		}
+		return condNode{op: CondOpAnd}, nil
+	}
+
+	o := cond.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case CondOpAnd, CondOpOr:
+		conds, ok := o.Val.([]Condition)
+		if !ok {
+			return condNode{}, fmt.Errorf("the value of %s must be []Condition, but got %T", o.Op, o.Val)
+		}
+
+		node := condNode{op: o.Op, nodes: make([]condNode, 0, len(conds))}
+		if negate {
+			if o.Op == CondOpAnd {
+				node.op = CondOpOr
+			} else {
+				node.op = CondOpAnd
+			}
+		}
+
+		for _, cond := range conds {
+			if cond == nil {
+				continue
+			}
+
+			sub, err := c.newCondNode(cond, negate)
+			if err != nil {
+				return condNode{}, err
+			}
+			node.nodes = append(node.nodes, sub)
+		}
+		return node, nil
+
+	case CondOpNot:
+		sub, _ := o.Val.(Condition)
+		if sub == nil {
+			return condNode{}, fmt.Errorf("the value of %s must be a Condition, but got %T", o.Op, o.Val)
+		}
+		return c.newCondNode(sub, !negate)
+	}
+
+	if negate {
+		negated, ok := negatedCondOps[o.Op]
+		if !ok {
+			return condNode{atom: o, negated: true}, nil
+		}
+		o.Op = negated
+	}
+
+	dom, known, err := c.opDomain(o)
+	if err != nil {
+		return condNode{}, err
+	}
+	return condNode{atom: o, dom: dom, known: known}, nil
+}
+
+// opDomain returns the domain of the atomic condition operation.
+//
+// If the domain is unknown, such as Like, return false.
+func (c *comparator) opDomain(o Op) (d domain, known bool, err error) {
+	point := func(v any) interval {
+		return interval{lo: bound{value: v}, hi: bound{value: v}}
+	}
+
+	switch o.Op {
+	case CondOpIsNull:
+		return domain{null: true}, true, nil
+
+	case CondOpIsNotNull:
+		return nonNullDomain, true, nil
+
+	case CondOpEqual, CondOpNotEqual, CondOpLess, CondOpLessEqual, CondOpGreater, CondOpGreaterEqual:
+		if o.Val == nil {
+			return
+		}
+	}
+
+	var ranges []interval
+	switch o.Op {
+	case CondOpEqual:
+		ranges = []interval{point(o.Val)}
+
+	case CondOpNotEqual:
+		ranges = []interval{
+			{lo: bound{inf: true}, hi: bound{value: o.Val, open: true}},
+			{lo: bound{value: o.Val, open: true}, hi: bound{inf: true}},
+		}
+
+	case CondOpLess:
+		ranges = []interval{{lo: bound{inf: true}, hi: bound{value: o.Val, open: true}}}
+
+	case CondOpLessEqual:
+		ranges = []interval{{lo: bound{inf: true}, hi: bound{value: o.Val}}}
+
+	case CondOpGreater:
+		ranges = []interval{{lo: bound{value: o.Val, open: true}, hi: bound{inf: true}}}
+
+	case CondOpGreaterEqual:
+		ranges = []interval{{lo: bound{value: o.Val}, hi: bound{inf: true}}}
+
+	case CondOpBetween, CondOpNotBetween:
+		b, ok := o.Val.(Boundary)
+		if !ok {
+			return domain{}, false, fmt.Errorf("the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		} else if b.Lower == nil || b.Upper == nil {
+			return
+		}
+
+		if o.Op == CondOpBetween {
+			ranges = []interval{{lo: bound{value: b.Lower}, hi: bound{value: b.Upper}}}
+		} else {
+			ranges = []interval{
+				{lo: bound{inf: true}, hi: bound{value: b.Lower, open: true}},
+				{lo: bound{value: b.Upper, open: true}, hi: bound{inf: true}},
+			}
+		}
+
+	case CondOpIn, CondOpNotIn:
+		vs := reflect.ValueOf(o.Val)
+		if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+			return domain{}, false, fmt.Errorf("the value of %s must be a slice, but got %T", o.Op, o.Val)
+		}
+
+		d = domain{ranges: []interval{}}
+		if o.Op == CondOpNotIn {
+			d = nonNullDomain
+		}
+
+		for i, _len := 0, vs.Len(); i < _len; i++ {
+			v := vs.Index(i).Interface()
+			if v == nil {
+				return domain{}, false, nil
+			}
+
+			if o.Op == CondOpIn {
+				d = c.union(d, domain{ranges: []interval{point(v)}})
+			} else {
+				d = c.intersect(d, domain{ranges: []interval{
+					{lo: bound{inf: true}, hi: bound{value: v, open: true}},
+					{lo: bound{value: v, open: true}, hi: bound{inf: true}},
+				}})
+			}
+		}
+		return d, true, nil
+
+	default:
+		return
+	}
+
+	return domain{ranges: c.normalize(ranges)}, true, nil
+}
+
+// keyDomain returns the domain of the key, which is the superset
+// of the values of the key satisfying the condition node.
+func (c *comparator) keyDomain(n condNode, key string) domain {
+	switch n.op {
+	case CondOpAnd:
+		d := fullDomain
+		for _, sub := range n.nodes {
+			d = c.intersect(d, c.keyDomain(sub, key))
+		}
+		return d
+
+	case CondOpOr:
+		d := domain{}
+		for _, sub := range n.nodes {
+			d = c.union(d, c.keyDomain(sub, key))
+		}
+		return d
+
+	default:
+		if n.known && n.atom.Key == key {
+			return n.dom
+		}
+		return fullDomain
+	}
+}
+
+// keys returns the keys of all the known leaves.
+func (n condNode) keys(keys map[string]struct{}) {
+	if n.op == "" {
+		if n.known {
+			keys[n.atom.Key] = struct{}{}
+		}
+		return
+	}
+
+	for _, sub := range n.nodes {
+		sub.keys(keys)
+	}
+}
+
+// singleKey returns the key if all the leaves are known and on the same key.
+func (n condNode) singleKey() (key string, ok bool) {
+	if n.op == "" {
+		return n.atom.Key, n.known
+	}
+
+	for _, sub := range n.nodes {
+		k, _ok := sub.singleKey()
+		if !_ok || (ok && k != key) {
+			return "", false
+		}
+		key, ok = k, true
+	}
+	return
+}
+
+// unsatisfiable reports whether the condition node is always false.
+func (c *comparator) unsatisfiable(n condNode) bool {
+	switch n.op {
+	case CondOpOr:
+		for _, sub := range n.nodes {
+			if !c.unsatisfiable(sub) {
+				return false
+			}
+		}
+		return true
+
+	case CondOpAnd:
+		for _, sub := range n.nodes {
+			if c.unsatisfiable(sub) {
+				return true
+			}
+		}
+
+		keys := make(map[string]struct{}, 4)
+		n.keys(keys)
+		for key := range keys {
+			if c.keyDomain(n, key).empty() {
+				return true
+			}
+		}
+		return false
+
+	default:
+		return n.known && n.dom.empty()
+	}
+}
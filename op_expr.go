@@ -0,0 +1,514 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Pre-define some expression operations.
+const (
+	ExprOpCol  = "Col"  // The value of the key Name.
+	ExprOpLit  = "Lit"  // The literal value Val.
+	ExprOpFunc = "Func" // The function Name called with Args.
+
+	// The arithmetic operations on two Args.
+	ExprOpAdd = "Add"
+	ExprOpSub = "Sub"
+	ExprOpMul = "Mul"
+	ExprOpDiv = "Div"
+
+	// The comparison operations on two Args, which are boolean.
+	ExprOpEqual        = "Equal"
+	ExprOpNotEqual     = "NotEqual"
+	ExprOpLess         = "Less"
+	ExprOpLessEqual    = "LessEqual"
+	ExprOpGreater      = "Greater"
+	ExprOpGreaterEqual = "GreaterEqual"
+)
+
+// Pre-define the names of the built-in expression functions.
+const (
+	FuncNow      = "NOW"      // NOW()
+	FuncLower    = "LOWER"    // LOWER(s)
+	FuncUpper    = "UPPER"    // UPPER(s)
+	FuncLength   = "LENGTH"   // LENGTH(s), the number of the characters
+	FuncAbs      = "ABS"      // ABS(n)
+	FuncCoalesce = "COALESCE" // COALESCE(v1, v2, ...)
+)
+
+var exprOperators = map[string]string{
+	ExprOpAdd:          "+",
+	ExprOpSub:          "-",
+	ExprOpMul:          "*",
+	ExprOpDiv:          "/",
+	ExprOpEqual:        "=",
+	ExprOpNotEqual:     "!=",
+	ExprOpLess:         "<",
+	ExprOpLessEqual:    "<=",
+	ExprOpGreater:      ">",
+	ExprOpGreaterEqual: ">=",
+}
+
+// Expr represents an expression, which is used as the value
+// of the condition and the updater, such as
+//
+//	Less("updated_at", Now().Sub(24*time.Hour)) // updated_at < NOW() - 24h
+//	Set("total", Col("price").Mul(Col("qty")))   // total = price * qty
+//
+// Or, it is used as the condition by the comparison, such as
+//
+//	Lower(Col("email")).Equal("a@b.com")      // LOWER(email) = 'a@b.com'
+//	Col("price").Mul(Col("qty")).Greater(100) // price * qty > 100
+type Expr struct {
+	Op   string
+	Name string // The key of ExprOpCol, or the function name of ExprOpFunc.
+	Val  any    // The literal value of ExprOpLit.
+	Args []Expr // The arguments of the function, or the operands.
+}
+
+// Col returns a new expression referring to the value of the key.
+func Col(key string) Expr { return Expr{Op: ExprOpCol, Name: key} }
+
+// Lit returns a new literal expression. If value is an Expr, return it.
+func Lit(value any) Expr {
+	if e, ok := value.(Expr); ok {
+		return e
+	}
+	return Expr{Op: ExprOpLit, Val: value}
+}
+
+// Func returns a new function expression, whose name is converted
+// to the upper case, and whose non-Expr arguments are converted by Lit.
+func Func(name string, args ...any) Expr {
+	exprs := make([]Expr, len(args))
+	for i, arg := range args {
+		exprs[i] = Lit(arg)
+	}
+	return Expr{Op: ExprOpFunc, Name: strings.ToUpper(name), Args: exprs}
+}
+
+// Now is equal to Func(FuncNow).
+func Now() Expr { return Func(FuncNow) }
+
+// Lower is equal to Func(FuncLower, value).
+func Lower(value any) Expr { return Func(FuncLower, value) }
+
+// Upper is equal to Func(FuncUpper, value).
+func Upper(value any) Expr { return Func(FuncUpper, value) }
+
+// Length is equal to Func(FuncLength, value).
+func Length(value any) Expr { return Func(FuncLength, value) }
+
+// Abs is equal to Func(FuncAbs, value).
+func Abs(value any) Expr { return Func(FuncAbs, value) }
+
+// Coalesce is equal to Func(FuncCoalesce, values...).
+func Coalesce(values ...any) Expr { return Func(FuncCoalesce, values...) }
+
+// Add returns a new expression, e + value.
+func (e Expr) Add(value any) Expr { return e.binary(ExprOpAdd, value) }
+
+// Sub returns a new expression, e - value.
+func (e Expr) Sub(value any) Expr { return e.binary(ExprOpSub, value) }
+
+// Mul returns a new expression, e * value.
+func (e Expr) Mul(value any) Expr { return e.binary(ExprOpMul, value) }
+
+// Div returns a new expression, e / value.
+func (e Expr) Div(value any) Expr { return e.binary(ExprOpDiv, value) }
+
+// Equal returns a new condition, e = value.
+func (e Expr) Equal(value any) Condition { return e.binary(ExprOpEqual, value).Condition() }
+
+// NotEqual returns a new condition, e != value.
+func (e Expr) NotEqual(value any) Condition { return e.binary(ExprOpNotEqual, value).Condition() }
+
+// Less returns a new condition, e < value.
+func (e Expr) Less(value any) Condition { return e.binary(ExprOpLess, value).Condition() }
+
+// LessEqual returns a new condition, e <= value.
+func (e Expr) LessEqual(value any) Condition { return e.binary(ExprOpLessEqual, value).Condition() }
+
+// Greater returns a new condition, e > value.
+func (e Expr) Greater(value any) Condition { return e.binary(ExprOpGreater, value).Condition() }
+
+// GreaterEqual returns a new condition, e >= value.
+func (e Expr) GreaterEqual(value any) Condition {
+	return e.binary(ExprOpGreaterEqual, value).Condition()
+}
+
+// Condition converts the boolean expression to the condition,
+// which is equal to New(CondOpExpr, "", e).Condition().
+func (e Expr) Condition() Condition { return New(CondOpExpr, "", e).Condition() }
+
+func (e Expr) binary(op string, value any) Expr {
+	return Expr{Op: op, Args: []Expr{e, Lit(value)}}
+}
+
+// Keys returns the keys referred by the expression in order,
+// which are deduplicated.
+func (e Expr) Keys() []string {
+	return e.appendKeys(nil)
+}
+
+func (e Expr) appendKeys(keys []string) []string {
+	if e.Op == ExprOpCol {
+		for _, key := range keys {
+			if key == e.Name {
+				return keys
+			}
+		}
+		return append(keys, e.Name)
+	}
+
+	for _, arg := range e.Args {
+		keys = arg.appendKeys(keys)
+	}
+	return keys
+}
+
+// scopeKeys returns a new expression with the scoped keys of ExprOpCol.
+func (e Expr) scopeKeys(name string) any {
+	switch e.Op {
+	case ExprOpCol:
+		e.Name = scopeKey(name, e.Name)
+
+	case ExprOpLit:
+
+	default:
+		args := make([]Expr, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = arg.scopeKeys(name).(Expr)
+		}
+		e.Args = args
+	}
+	return e
+}
+
+// String returns the string representation of the expression,
+// such as "(price * qty)" and "LOWER(email)".
+func (e Expr) String() string {
+	switch e.Op {
+	case ExprOpCol:
+		return e.Name
+
+	case ExprOpLit:
+		switch v := e.Val.(type) {
+		case nil:
+			return "NULL"
+		case string:
+			return strconv.Quote(v)
+		default:
+			return fmt.Sprint(v)
+		}
+
+	case ExprOpFunc:
+		args := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = arg.String()
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+
+	default:
+		if operator, ok := exprOperators[e.Op]; ok && len(e.Args) == 2 {
+			return "(" + e.Args[0].String() + " " + operator + " " + e.Args[1].String() + ")"
+		}
+		return fmt.Sprintf("%s%v", e.Op, e.Args)
+	}
+}
+
+// Eval evaluates the expression in memory, which uses get
+// to get the value of the key referred by ExprOpCol.
+//
+// The relative times and the function NOW are resolved against
+// the same instant returned by Clock, which is equal to e.EvalAt(get, Clock()).
+//
+// Like SQL, the arithmetic and comparison operations return nil
+// if any operand is nil. The arithmetic operations support the numbers,
+// time.Time with time.Duration, and the difference of two time.Time.
+func (e Expr) Eval(get func(key string) (value any, ok bool)) (any, error) {
+	return e.EvalAt(get, Clock())
+}
+
+// EvalAt is the same as Eval, but resolves the relative times
+// and the builtin function NOW against now.
+func (e Expr) EvalAt(get func(key string) (value any, ok bool), now time.Time) (any, error) {
+	switch e.Op {
+	case ExprOpCol:
+		if get != nil {
+			if v, ok := get(e.Name); ok {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("op.Expr: unknown key '%s'", e.Name)
+
+	case ExprOpLit:
+		if t, ok := e.Val.(RelTime); ok {
+			return t.At(now), nil
+		}
+		return e.Val, nil
+
+	case ExprOpFunc:
+		f, ok := GetExprFunc(e.Name)
+		if !ok {
+			return nil, fmt.Errorf("op.Expr: unknown function '%s'", e.Name)
+		}
+
+		if len(e.Args) < f.MinArgs || (f.MaxArgs >= 0 && len(e.Args) > f.MaxArgs) {
+			return nil, fmt.Errorf("op.Expr: invalid number of arguments of function '%s': %d", e.Name, len(e.Args))
+		}
+
+		if f.now {
+			return now, nil
+		}
+
+		args, err := e.evalArgs(get, now)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := f.Eval(args...)
+		if err != nil {
+			return nil, fmt.Errorf("op.Expr: %s: %w", e.Name, err)
+		}
+		return v, nil
+	}
+
+	if _, ok := exprOperators[e.Op]; !ok {
+		return nil, fmt.Errorf("op.Expr: unsupported expression operation '%s'", e.Op)
+	} else if len(e.Args) != 2 {
+		return nil, fmt.Errorf("op.Expr: %s must have 2 operands, but got %d", e.Op, len(e.Args))
+	}
+
+	args, err := e.evalArgs(get, now)
+	if err != nil {
+		return nil, err
+	}
+
+	a, b := args[0], args[1]
+	if a == nil || b == nil {
+		return nil, nil
+	}
+
+	switch e.Op {
+	case ExprOpAdd, ExprOpSub, ExprOpMul, ExprOpDiv:
+		return evalArithmetic(e.Op, a, b)
+	}
+
+	r, ok := compareValues(a, b)
+	if !ok {
+		return nil, fmt.Errorf("op.Expr: cannot compare %T with %T", a, b)
+	}
+
+	switch e.Op {
+	case ExprOpEqual:
+		return r == 0, nil
+	case ExprOpNotEqual:
+		return r != 0, nil
+	case ExprOpLess:
+		return r < 0, nil
+	case ExprOpLessEqual:
+		return r <= 0, nil
+	case ExprOpGreater:
+		return r > 0, nil
+	default:
+		return r >= 0, nil
+	}
+}
+
+func (e Expr) evalArgs(get func(string) (any, bool), now time.Time) ([]any, error) {
+	args := make([]any, len(e.Args))
+	for i, arg := range e.Args {
+		v, err := arg.EvalAt(get, now)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+func evalArithmetic(op string, a, b any) (any, error) {
+	if n, ok := toNumber(a); ok {
+		if m, ok := toNumber(b); ok {
+			var r number
+			switch op {
+			case ExprOpAdd:
+				r = n.add(m)
+			case ExprOpSub:
+				r = n.add(m.neg())
+			case ExprOpMul:
+				r = n.mul(m)
+			default:
+				if r, ok = n.div(m); !ok {
+					return nil, errors.New("op.Expr: division by zero")
+				}
+			}
+			return r.value(commonType(a, b)), nil
+		}
+	}
+
+	switch v1 := a.(type) {
+	case time.Time:
+		switch v2 := b.(type) {
+		case time.Duration:
+			switch op {
+			case ExprOpAdd:
+				return v1.Add(v2), nil
+			case ExprOpSub:
+				return v1.Add(-v2), nil
+			}
+
+		case time.Time:
+			if op == ExprOpSub {
+				return v1.Sub(v2), nil
+			}
+		}
+
+	case time.Duration:
+		if v2, ok := b.(time.Time); ok && op == ExprOpAdd {
+			return v2.Add(v1), nil
+		}
+	}
+
+	return nil, fmt.Errorf("op.Expr: unsupported %s between %T and %T", op, a, b)
+}
+
+// HasExpr reports whether the operation or its sub-operations
+// contain any expression, including CondOpExpr.
+func HasExpr(o Oper) (has bool) {
+	mapValues(o, func(v any) (any, bool) {
+		if _, ok := v.(Expr); ok {
+			has = true
+		}
+		return nil, false
+	})
+	return
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// ExprFunc represents a function used by the function expression.
+//
+// The renderers, such as the SQL builder, should implement the functions
+// by the names, and Expr.Eval uses Eval to evaluate them in memory.
+type ExprFunc struct {
+	Name    string
+	MinArgs int
+	MaxArgs int // If negative, no limit.
+	Eval    func(args ...any) (any, error)
+
+	now bool // The builtin NOW, which Expr.EvalAt resolves against its now.
+}
+
+var exprFuncs = make(map[string]ExprFunc, 8)
+
+func init() {
+	RegisterExprFunc(ExprFunc{Name: FuncNow, Eval: evalNow, now: true})
+	RegisterExprFunc(ExprFunc{Name: FuncLower, MinArgs: 1, MaxArgs: 1, Eval: evalString(strings.ToLower)})
+	RegisterExprFunc(ExprFunc{Name: FuncUpper, MinArgs: 1, MaxArgs: 1, Eval: evalString(strings.ToUpper)})
+	RegisterExprFunc(ExprFunc{Name: FuncLength, MinArgs: 1, MaxArgs: 1, Eval: evalLength})
+	RegisterExprFunc(ExprFunc{Name: FuncAbs, MinArgs: 1, MaxArgs: 1, Eval: evalAbs})
+	RegisterExprFunc(ExprFunc{Name: FuncCoalesce, MinArgs: 1, MaxArgs: -1, Eval: evalCoalesce})
+}
+
+// RegisterExprFunc registers the expression function, whose name is converted
+// to the upper case, which overrides the registered one with the same name.
+//
+// The registry is not guarded by any lock, so it must be called only
+// during the program initialization, such as in init, before any expression
+// is evaluated. If the name is empty or Eval is nil, panic.
+func RegisterExprFunc(f ExprFunc) {
+	if f.Name == "" {
+		panic(fmt.Errorf("op.RegisterExprFunc: the function name must not be empty"))
+	}
+	if f.Eval == nil {
+		panic(fmt.Errorf("op.RegisterExprFunc: the function '%s' has no Eval", f.Name))
+	}
+
+	f.Name = strings.ToUpper(f.Name)
+	exprFuncs[f.Name] = f
+}
+
+// GetExprFunc returns the registered expression function by the name,
+// which is case-insensitive.
+func GetExprFunc(name string) (f ExprFunc, ok bool) {
+	f, ok = exprFuncs[strings.ToUpper(name)]
+	return
+}
+
+// ExprFuncNames returns the sorted names of all the registered expression functions.
+func ExprFuncNames() []string {
+	names := make([]string, 0, len(exprFuncs))
+	for name := range exprFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func evalNow(...any) (any, error) { return Clock(), nil }
+
+func evalString(f func(string) string) func(...any) (any, error) {
+	return func(args ...any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		if v := reflect.ValueOf(args[0]); v.Kind() == reflect.String {
+			return f(v.String()), nil
+		}
+		return nil, fmt.Errorf("the argument must be a string, but got %T", args[0])
+	}
+}
+
+func evalLength(args ...any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	if v := reflect.ValueOf(args[0]); v.Kind() == reflect.String {
+		return utf8.RuneCountInString(v.String()), nil
+	}
+	return nil, fmt.Errorf("the argument must be a string, but got %T", args[0])
+}
+
+func evalAbs(args ...any) (any, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	if n, ok := toNumber(args[0]); ok {
+		return n.abs().value(reflect.TypeOf(args[0])), nil
+	}
+	return nil, fmt.Errorf("the argument must be a number, but got %T", args[0])
+}
+
+func evalCoalesce(args ...any) (any, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
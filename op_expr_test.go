@@ -0,0 +1,194 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExprString(t *testing.T) {
+	for i, c := range []struct {
+		expr   Expr
+		expect string
+	}{
+		{Col("price").Mul(Col("qty")), "(price * qty)"},
+		{Lower(Col("email")), "LOWER(email)"},
+		{Coalesce(Col("a"), nil, "x"), `COALESCE(a, NULL, "x")`},
+		{Func("concat", Col("a"), 1), "CONCAT(a, 1)"},
+		{Col("a").Add(1).Sub(Col("b")).Div(2), "(((a + 1) - b) / 2)"},
+	} {
+		if s := c.expr.String(); s != c.expect {
+			t.Errorf("%d: expect '%s', but got '%s'", i, c.expect, s)
+		}
+	}
+
+	cond := Col("price").Mul(Col("qty")).Greater(100)
+	if o := cond.Op(); o.Op != CondOpExpr || o.Val.(Expr).String() != "((price * qty) > 100)" {
+		t.Errorf("unexpected condition %v", o)
+	}
+
+	if keys := Col("a").Add(Col("b")).Mul(Col("a")).Keys(); !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("expect the keys %v, but got %v", []string{"a", "b"}, keys)
+	}
+}
+
+func TestExprEval(t *testing.T) {
+	now := time.Now()
+	row := map[string]any{
+		"price":      int64(20),
+		"qty":        int64(6),
+		"ratio":      0.5,
+		"email":      "Abc@Example.COM",
+		"nickname":   nil,
+		"updated_at": now.Add(-48 * time.Hour),
+	}
+	get := func(key string) (v any, ok bool) { v, ok = row[key]; return }
+
+	for i, c := range []struct {
+		expr   Expr
+		expect any
+	}{
+		{Col("price").Mul(Col("qty")), int64(120)},
+		{Col("price").Mul(Col("ratio")), 10.0},
+		{Col("price").Div(Col("qty")), int64(3)},
+		{Col("price").Sub(Col("qty")).Add(1), 15},
+		{Col("price").Add(Col("nickname")), nil},
+		{Lit(int64(math.MaxInt64)).Add(int64(1)), float64(math.MaxInt64) + 1},
+		{Lit(int64(math.MinInt64)).Sub(int64(1)), float64(math.MinInt64) - 1},
+		{Lit(int64(math.MaxInt64)).Mul(int64(2)), float64(math.MaxInt64) * 2},
+		{Lit(int64(math.MinInt64)).Mul(int64(-1)), -float64(math.MinInt64)},
+		{Lit(int64(math.MinInt64)).Div(int64(-1)), -float64(math.MinInt64)},
+		{Lower(Col("email")), "abc@example.com"},
+		{Upper(Col("nickname")), nil},
+		{Length(Col("email")), 15},
+		{Abs(Lit(-3)), 3},
+		{Coalesce(Col("nickname"), Col("email")), "Abc@Example.COM"},
+		{Lit(now).Sub(time.Hour), now.Add(-time.Hour)},
+		{Lit(now).Sub(Lit(now.Add(-time.Hour))), time.Hour},
+		{Col("price").Mul(Col("qty")).Greater(100).Op().Val.(Expr), true},
+		{Lower(Col("email")).Equal("abc@example.com").Op().Val.(Expr), true},
+		{Col("nickname").Equal("a").Op().Val.(Expr), nil},
+		{Col("updated_at").Less(Lit(now).Sub(24 * time.Hour)).Op().Val.(Expr), true},
+	} {
+		if v, err := c.expr.Eval(get); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(v, c.expect) {
+			t.Errorf("%d: expect %T(%v), but got %T(%v)", i, c.expect, c.expect, v, v)
+		}
+	}
+
+	for i, c := range []struct {
+		expr Expr
+		err  string
+	}{
+		{Col("unknown"), "unknown key"},
+		{Func("unknown"), "unknown function"},
+		{Func(FuncLower), "invalid number of arguments"},
+		{Col("price").Div(0), "division by zero"},
+		{Col("email").Add(1), "unsupported Add"},
+		{Lower(Col("price")), "must be a string"},
+		{Col("email").Greater(1).Op().Val.(Expr), "cannot compare"},
+	} {
+		if _, err := c.expr.Eval(get); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%d: expect the error containing '%s', but got '%v'", i, c.err, err)
+		}
+	}
+
+	if v, err := Now().Eval(nil); err != nil {
+		t.Error(err)
+	} else if _, ok := v.(time.Time); !ok {
+		t.Errorf("expect time.Time, but got %T", v)
+	}
+
+	// All the relative times and NOW are resolved against the same instant.
+	defer func(clock func() time.Time) { Clock = clock }(Clock)
+	now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	Clock = func() time.Time { now = now.Add(time.Second); return now }
+	if v, err := Now().Sub(Lit(Ago(time.Hour))).Eval(nil); err != nil {
+		t.Error(err)
+	} else if v != time.Hour {
+		t.Errorf("expect %v, but got %v", time.Hour, v)
+	}
+}
+
+func TestExprFunc(t *testing.T) {
+	RegisterExprFunc(ExprFunc{Name: "double", MinArgs: 1, MaxArgs: 1, Eval: func(args ...any) (any, error) {
+		return args[0].(int) * 2, nil
+	}})
+	defer delete(exprFuncs, "DOUBLE")
+
+	if _, ok := GetExprFunc("Double"); !ok {
+		t.Errorf("not found the registered function")
+	}
+
+	if v, err := Func("double", 2).Eval(nil); err != nil {
+		t.Error(err)
+	} else if v != 4 {
+		t.Errorf("expect %v, but got %v", 4, v)
+	}
+
+	names := ExprFuncNames()
+	if !reflect.DeepEqual(names, []string{"ABS", "COALESCE", "DOUBLE", "LENGTH", "LOWER", "NOW", "UPPER"}) {
+		t.Errorf("unexpected function names %v", names)
+	}
+}
+
+func TestExprValue(t *testing.T) {
+	cond := And(Equal("id", 1), Less("updated_at", Now().Sub(Param("duration"))))
+	if !HasExpr(cond) {
+		t.Errorf("expect having the expression")
+	}
+	if HasExpr(Equal("id", 1)) {
+		t.Errorf("unexpected expression")
+	}
+
+	bound, err := Bind(cond, map[string]any{"duration": time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := And(Equal("id", 1), Less("updated_at", Now().Sub(time.Hour)))
+	if !reflect.DeepEqual(bound, expect) {
+		t.Errorf("expect %v, but got %v", expect, bound)
+	}
+
+	f := Fingerprinter{IgnoreValues: true}
+	if f.String(Less("a", Now().Sub(1))) != f.String(Less("a", Now().Sub(2))) {
+		t.Errorf("expect the same shape")
+	}
+	if f.String(Less("a", Now().Sub(1))) == f.String(Less("a", Now().Add(1))) {
+		t.Errorf("expect the different shapes")
+	}
+}
+
+func TestExprScope(t *testing.T) {
+	cond := Col("price").Mul(Col("qty")).Greater(100).Scope("t")
+	expect := Col("t.price").Mul(Col("t.qty")).Greater(100)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+	if scopes := Scopes(cond); !reflect.DeepEqual(scopes, []string{"t"}) {
+		t.Errorf("expect the scopes %v, but got %v", []string{"t"}, scopes)
+	}
+
+	cond = Equal("total", Col("price").Mul(2)).Scope("t")
+	if expect := Equal("t.total", Col("t.price").Mul(2)); !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fingerprint is equal to Fingerprinter{}.Sum(o).
+func Fingerprint(o Oper) []byte { return Fingerprinter{}.Sum(o) }
+
+// FingerprintString is equal to Fingerprinter{}.String(o).
+func FingerprintString(o Oper) string { return Fingerprinter{}.String(o) }
+
+// Fingerprinter is used to compute the deterministic fingerprint
+// of the operation, which can be used as the cache key.
+//
+// The fingerprint is the SHA-256 hash of the canonical form of the operation
+// tree, which has the following rules:
+//
+//   - The lazy operation is calculated first.
+//   - The tags are sorted by the key.
+//   - The children of And and Or are sorted, the nested And in And and
+//     the nested Or in Or without tags are flattened, and And and Or
+//     with only one child are replaced by the child.
+//   - The values of In and NotIn are sorted and deduplicated.
+//   - The integers, and the floats with the integral value, are regarded
+//     as the same number, such as int8(1), uint(1) and 1.0.
+//   - time.Time is converted to UTC.
+//   - Param is different from the string with the same content.
+//   - RelTime is not resolved, so the fingerprint is stable over time.
+//   - The map is sorted by the key, and the pointer is dereferenced.
+//
+// The function value, such as the lazy function in the value, is ignored.
+type Fingerprinter struct {
+	// IgnoreValues ignores the values of the operations except
+	// the sub-operations and the structure of the expressions, so the operations with the same shape,
+	// such as Equal("id", 1) and Equal("id", 2), have the same fingerprint,
+	// which can be used to group the queries for the metrics.
+	IgnoreValues bool
+}
+
+// Sum returns the SHA-256 fingerprint of the operation.
+func (f Fingerprinter) Sum(o Oper) []byte {
+	sum := sha256.Sum256([]byte(f.canonical(o)))
+	return sum[:]
+}
+
+// String returns the hex-encoded SHA-256 fingerprint of the operation.
+func (f Fingerprinter) String(o Oper) string {
+	return hex.EncodeToString(f.Sum(o))
+}
+
+// canonical returns the canonical form of the operation.
+func (f Fingerprinter) canonical(o Oper) string {
+	if o == nil {
+		return "nil"
+	}
+
+	op := o.Op()
+	if op.Lazy != nil {
+		op = op.Lazy(op)
+	}
+
+	var value string
+	switch op.Op {
+	case CondOpAnd, CondOpOr:
+		if conds, ok := op.Val.([]Condition); ok {
+			children := f.commutative(op.Op, conds)
+			if len(children) == 1 && len(op.Tags) == 0 {
+				return children[0]
+			}
+			value = "[" + strings.Join(children, ",") + "]"
+		}
+
+	case CondOpIn, CondOpNotIn:
+		if f.IgnoreValues {
+			value = "?"
+		} else if vs := reflect.ValueOf(op.Val); vs.Kind() == reflect.Slice || vs.Kind() == reflect.Array {
+			values := make([]string, 0, vs.Len())
+			for i, _len := 0, vs.Len(); i < _len; i++ {
+				values = append(values, f.value(vs.Index(i)))
+			}
+			value = "{" + strings.Join(sortUnique(values), ",") + "}"
+		}
+	}
+
+	if value == "" {
+		switch {
+		case !f.IgnoreValues:
+			value = f.value(reflect.ValueOf(op.Val))
+
+		default:
+			if e, ok := op.Val.(Expr); ok {
+				value = f.expr(e)
+			} else if c, ok := op.Val.(Condition); ok && op.Op == UpdateOpPull {
+				value = f.canonical(c)
+			} else if subs := SubOpers(op.Oper()); subs != nil {
+				values := make([]string, len(subs))
+				for i, sub := range subs {
+					values[i] = f.canonical(sub)
+				}
+				value = "[" + strings.Join(values, ",") + "]"
+			} else {
+				value = "?"
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("op(")
+	b.WriteString(strconv.Quote(op.Kind))
+	b.WriteByte(',')
+	b.WriteString(strconv.Quote(op.Op))
+	b.WriteByte(',')
+	b.WriteString(strconv.Quote(op.Key))
+	b.WriteByte(',')
+	if len(op.Tags) > 0 {
+		tags := make([]string, 0, len(op.Tags))
+		for k, v := range op.Tags {
+			tags = append(tags, strconv.Quote(k)+":"+strconv.Quote(v))
+		}
+		sort.Strings(tags)
+		b.WriteString(strings.Join(tags, ","))
+	}
+	b.WriteByte(',')
+	b.WriteString(value)
+	b.WriteByte(')')
+	return b.String()
+}
+
+// commutative returns the sorted canonical forms of the children of And or Or.
+func (f Fingerprinter) commutative(op string, conds []Condition) []string {
+	children := make([]string, 0, len(conds))
+	for _, cond := range conds {
+		if cond == nil {
+			continue
+		}
+
+		if o := cond.Op(); o.Op == op && o.Lazy == nil && len(o.Tags) == 0 {
+			if subs, ok := o.Val.([]Condition); ok {
+				children = append(children, f.commutative(op, subs)...)
+				continue
+			}
+		}
+
+		children = append(children, f.canonical(cond))
+	}
+
+	sort.Strings(children)
+	return children
+}
+
+func sortUnique(ss []string) []string {
+	sort.Strings(ss)
+	results := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			results = append(results, s)
+		}
+	}
+	return results
+}
+
+// expr returns the canonical form of the expression.
+func (f Fingerprinter) expr(e Expr) string {
+	var value string
+	if e.Op == ExprOpLit {
+		if f.IgnoreValues {
+			value = "?"
+		} else {
+			value = f.value(reflect.ValueOf(e.Val))
+		}
+	}
+
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = f.expr(arg)
+	}
+
+	return "expr(" + strconv.Quote(e.Op) + "," + strconv.Quote(e.Name) + "," +
+		value + ",[" + strings.Join(args, ",") + "])"
+}
+
+// value returns the canonical form of the value.
+func (f Fingerprinter) value(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+
+	if v.CanInterface() {
+		switch i := v.Interface().(type) {
+		case time.Time:
+			return "t:" + i.UTC().Format(time.RFC3339Nano)
+
+		case Param:
+			return "p:" + strconv.Quote(string(i))
+
+		case Expr:
+			return f.expr(i)
+
+		case RelTime:
+			return "r:" + strconv.Quote(i.String())
+
+		case Oper:
+			if v.Kind() != reflect.Ptr || !v.IsNil() {
+				return f.canonical(i)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return "nil"
+		}
+		return f.value(v.Elem())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "n:" + strconv.FormatInt(v.Int(), 10)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "n:" + strconv.FormatUint(v.Uint(), 10)
+
+	case reflect.Float32, reflect.Float64:
+		x := v.Float()
+		if x == math.Trunc(x) && x >= math.MinInt64 && x < math.MaxInt64 {
+			return "n:" + strconv.FormatInt(int64(x), 10)
+		}
+		return "n:" + strconv.FormatFloat(x, 'g', -1, 64)
+
+	case reflect.String:
+		return "s:" + strconv.Quote(v.String())
+
+	case reflect.Bool:
+		return "b:" + strconv.FormatBool(v.Bool())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return "x:" + hex.EncodeToString(v.Bytes())
+		}
+
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = f.value(v.Index(i))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+
+	case reflect.Map:
+		values := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			values = append(values, f.value(iter.Key())+":"+f.value(iter.Value()))
+		}
+		sort.Strings(values)
+		return "{" + strings.Join(values, ",") + "}"
+
+	case reflect.Struct:
+		vtype := v.Type()
+		values := make([]string, vtype.NumField())
+		for i := range values {
+			values[i] = vtype.Field(i).Name + ":" + f.value(v.Field(i))
+		}
+		return vtype.String() + "{" + strings.Join(values, ",") + "}"
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.Kind().String()
+
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	now := time.Now()
+	for i, c := range []struct {
+		a, b  Oper
+		equal bool
+	}{
+		{Eq("id", 1), Eq("id", 1), true},
+		{Eq("id", 1), Eq("id", int64(1)), true},
+		{Eq("id", uint8(1)), Eq("id", 1.0), true},
+		{Eq("id", 1), Eq("id", 1.5), false},
+		{Eq("id", 1), Eq("id", "1"), false},
+		{Eq("id", 1), Eq("id", 2), false},
+		{Eq("id", 1), NotEq("id", 1), false},
+		{Eq("id", 1), Eq("uid", 1), false},
+		{Eq("t", now), Eq("t", now.UTC()), true},
+		{Eq("id", Param("id")), Eq("id", Param("id")), true},
+		{Eq("id", Param("id")), Eq("id", "id"), false},
+		{And(Eq("a", 1), Eq("b", 2)), And(Eq("b", 2), Eq("a", 1)), true},
+		{Or(Eq("a", 1), Eq("b", 2)), Or(Eq("b", 2), Eq("a", 1)), true},
+		{And(Eq("a", 1), Eq("b", 2)), Or(Eq("a", 1), Eq("b", 2)), false},
+		{And(Eq("a", 1), And(Eq("b", 2), Eq("c", 3))), And(Eq("c", 3), Eq("b", 2), Eq("a", 1)), true},
+		{And(Eq("a", 1)), Eq("a", 1), true},
+		{And(Eq("a", 1), nil), Eq("a", 1), true},
+		{In("id", []int{3, 1, 2, 1}), In("id", []int64{1, 2, 3}), true},
+		{In("id", []int{1, 2}), In("id", []int{1, 2, 3}), false},
+		{Eq("id", 1).Op().WithTag("k", "v").Condition(), Eq("id", 1), false},
+		{Eq("id", 1).Op().WithTags(map[string]string{"a": "1", "b": "2"}).Condition(),
+			Eq("id", 1).Op().AppendTag("b", "2").AppendTag("a", "1").Condition(), true},
+		{Not(Eq("a", 1)), Not(Eq("a", 1.0)), true},
+		{Not(Eq("a", 1)), Not(Eq("a", 2)), false},
+		{Set("a", 1), Set("a", 1.0), true},
+		{Batch(Set("a", 1), Set("b", 2)), Batch(Set("b", 2), Set("a", 1)), false},
+		{Order("a", SortAsc), Order("a", SortDesc), false},
+		{PageSize(1, 10), PageSize(1, 10), true},
+		{PageSize(1, 10), PageSize(2, 10), false},
+		{nil, nil, true},
+	} {
+		fa, fb := FingerprintString(c.a), FingerprintString(c.b)
+		if equal := fa == fb; equal != c.equal {
+			t.Errorf("%d: expect equal=%v, but got %v: %v, %v", i, c.equal, equal, c.a, c.b)
+		}
+	}
+
+	if sum := Fingerprint(Eq("id", 1)); len(sum) != 32 {
+		t.Errorf("expect the fingerprint length %d, but got %d", 32, len(sum))
+	}
+}
+
+func TestFingerprinterIgnoreValues(t *testing.T) {
+	f := Fingerprinter{IgnoreValues: true}
+	for i, c := range []struct {
+		a, b  Oper
+		equal bool
+	}{
+		{Eq("id", 1), Eq("id", 2), true},
+		{Eq("id", 1), Eq("id", "abc"), true},
+		{Eq("id", 1), NotEq("id", 1), false},
+		{Eq("id", 1), Eq("uid", 1), false},
+		{In("id", []int{1}), In("id", []int{1, 2, 3}), true},
+		{And(Eq("a", 1), Gt("b", 2)), And(Gt("b", 3), Eq("a", 4)), true},
+		{And(Eq("a", 1), Gt("b", 2)), And(Eq("a", 1), Le("b", 2)), false},
+		{Not(Eq("a", 1)), Not(Eq("a", 2)), true},
+		{Batch(Set("a", 1), Set("b", 2)), Batch(Set("a", 3), Set("b", 4)), true},
+		{PageSize(1, 10), PageSize(2, 20), true},
+		{PullIf("items", Eq("qty", 1)), PullIf("items", Eq("qty", 2)), true},
+		{PullIf("items", Eq("qty", 1)), PullIf("items", Gt("qty", 1)), false},
+	} {
+		if equal := f.String(c.a) == f.String(c.b); equal != c.equal {
+			t.Errorf("%d: expect equal=%v, but got %v: %v, %v", i, c.equal, equal, c.a, c.b)
+		}
+	}
+}
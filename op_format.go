@@ -0,0 +1,341 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format formats the condition to the expression, which can be parsed
+// by ParseCondition to the equal condition. For example,
+//
+//	Format(And(GtEq("age", 18), Or(Eq("a", "x"), IsNull("b"))))
+//	// age >= 18 and (a = "x" or b is null)
+//
+// The value must be one of nil, bool, string, integers, floats, time.Time,
+// or the slice of them for In and NotIn. time.Time is formatted as the string
+// with the layout time.RFC3339Nano. The integer is parsed back as int64,
+// and the float is parsed back as float64.
+//
+// Equal and NotEqual by nil are formatted as "key is null" and
+// "key is not null", which are parsed back as IsNull and IsNotNull.
+// The other comparisons by nil are not supported.
+//
+// Since ParseCondition does not support the expressions, CondOpExpr
+// and Expr values are not supported, and RelTime must be resolved
+// by ResolveTimes first.
+//
+// If the condition or the value is not supported, return an error.
+func Format(c Condition) (string, error) {
+	var b strings.Builder
+	if err := formatCondition(&b, c); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var formatOperators = map[string]string{
+	CondOpEqual:           "=",
+	CondOpNotEqual:        "!=",
+	CondOpLess:            "<",
+	CondOpLessEqual:       "<=",
+	CondOpGreater:         ">",
+	CondOpGreaterEqual:    ">=",
+	CondOpEqualKey:        "=",
+	CondOpNotEqualKey:     "!=",
+	CondOpLessKey:         "<",
+	CondOpLessEqualKey:    "<=",
+	CondOpGreaterKey:      ">",
+	CondOpGreaterEqualKey: ">=",
+}
+
+func formatCondition(b *strings.Builder, c Condition) error {
+	if c == nil {
+		return fmt.Errorf("op.Format: the condition is nil")
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case CondOpAnd, CondOpOr:
+		conds, _ := o.Val.([]Condition)
+		if len(conds) == 0 {
+			return fmt.Errorf("op.Format: empty %s", o.Op)
+		}
+
+		for i, cond := range conds {
+			if i > 0 {
+				b.WriteString(" " + strings.ToLower(o.Op) + " ")
+			}
+
+			if err := formatSubCondition(b, cond, needParen(o.Op, cond)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case CondOpNot:
+		b.WriteString("not ")
+		cond, _ := o.Val.(Condition)
+		return formatSubCondition(b, cond, needParen(o.Op, cond))
+
+	case CondOpIsNull:
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		b.WriteString(" is null")
+		return nil
+
+	case CondOpIsNotNull:
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		b.WriteString(" is not null")
+		return nil
+
+	case CondOpEqualKey, CondOpNotEqualKey, CondOpLessKey,
+		CondOpLessEqualKey, CondOpGreaterKey, CondOpGreaterEqualKey:
+		other, ok := o.Val.(string)
+		if !ok {
+			return fmt.Errorf("op.Format: the value of %s must be a key string, but got %T", o.Op, o.Val)
+		}
+
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		b.WriteString(" " + formatOperators[o.Op] + " ")
+		return formatKey(b, other)
+
+	case CondOpEqual, CondOpNotEqual, CondOpLess, CondOpLessEqual, CondOpGreater, CondOpGreaterEqual:
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+
+		if o.Val == nil {
+			switch o.Op {
+			case CondOpEqual:
+				b.WriteString(" is null")
+			case CondOpNotEqual:
+				b.WriteString(" is not null")
+			default:
+				return fmt.Errorf("op.Format: null cannot be compared by %s", o.Op)
+			}
+			return nil
+		}
+
+		b.WriteString(" " + formatOperators[o.Op] + " ")
+		return formatValue(b, o.Val)
+
+	case CondOpLike, CondOpNotLike:
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		if o.Op == CondOpNotLike {
+			b.WriteString(" not")
+		}
+		b.WriteString(" like ")
+
+		if _, ok := o.Val.(string); !ok {
+			return fmt.Errorf("op.Format: the value of %s must be a string, but got %T", o.Op, o.Val)
+		}
+		return formatValue(b, o.Val)
+
+	case CondOpIn, CondOpNotIn:
+		vs := reflect.ValueOf(o.Val)
+		if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+			return fmt.Errorf("op.Format: the value of %s must be a slice, but got %T", o.Op, o.Val)
+		}
+
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		if o.Op == CondOpNotIn {
+			b.WriteString(" not")
+		}
+		b.WriteString(" in (")
+		for i, _len := 0, vs.Len(); i < _len; i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := formatValue(b, vs.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return nil
+
+	case CondOpBetween, CondOpNotBetween:
+		v, ok := o.Val.(Boundary)
+		if !ok {
+			return fmt.Errorf("op.Format: the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		}
+
+		if err := formatKey(b, o.Key); err != nil {
+			return err
+		}
+		if o.Op == CondOpNotBetween {
+			b.WriteString(" not")
+		}
+		b.WriteString(" between ")
+		if err := formatValue(b, v.Lower); err != nil {
+			return err
+		}
+		b.WriteString(" and ")
+		return formatValue(b, v.Upper)
+
+	case CondOpExpr:
+		return fmt.Errorf("op.Format: unsupported expression '%v'", o.Val)
+
+	default:
+		return fmt.Errorf("op.Format: unsupported condition operation '%s'", o.Op)
+	}
+}
+
+// needParen reports whether the sub-condition c of the condition operation
+// parent needs the parentheses, where "and" binds tighter than "or",
+// which keeps the structure of the condition tree after being parsed.
+func needParen(parent string, c Condition) bool {
+	if c == nil {
+		return false
+	}
+
+	switch o := c.Op(); o.Op {
+	case CondOpAnd:
+		return parent != CondOpOr && len(o.Val.([]Condition)) > 1
+	case CondOpOr:
+		return len(o.Val.([]Condition)) > 1
+	default:
+		return false
+	}
+}
+
+func formatSubCondition(b *strings.Builder, c Condition, paren bool) error {
+	if paren {
+		b.WriteByte('(')
+	}
+	if err := formatCondition(b, c); err != nil {
+		return err
+	}
+	if paren {
+		b.WriteByte(')')
+	}
+	return nil
+}
+
+func formatKey(b *strings.Builder, key string) error {
+	if strings.IndexByte(key, '`') > -1 {
+		return fmt.Errorf("op.Format: the key '%s' contains the backquote", key)
+	}
+
+	plain := key != "" && !isCondKeyword(key) && key[0] != '.' && (key[0] < '0' || key[0] > '9')
+	for _, r := range key {
+		if !plain {
+			break
+		}
+		plain = isIdentRune(r)
+	}
+
+	if plain {
+		b.WriteString(key)
+	} else {
+		b.WriteByte('`')
+		b.WriteString(key)
+		b.WriteByte('`')
+	}
+	return nil
+}
+
+func formatValue(b *strings.Builder, value any) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		formatString(b, v)
+	case time.Time:
+		formatString(b, v.Format(time.RFC3339Nano))
+	case Param:
+		return fmt.Errorf("op.Format: unbound param '%s'", string(v))
+	case Expr:
+		return fmt.Errorf("op.Format: unsupported expression '%s'", v.String())
+	case RelTime:
+		return fmt.Errorf("op.Format: unresolved relative time '%s'", v.String())
+
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return fmt.Errorf("op.Format: unsupported float value %v", f)
+			}
+
+			s := strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())
+			if !strings.ContainsAny(s, ".e") {
+				s += ".0"
+			}
+			b.WriteString(s)
+
+		case reflect.String:
+			formatString(b, rv.String())
+
+		case reflect.Bool:
+			b.WriteString(strconv.FormatBool(rv.Bool()))
+
+		default:
+			return fmt.Errorf("op.Format: unsupported value type %T", value)
+		}
+	}
+	return nil
+}
+
+func formatString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for len(s) > 0 {
+		r, n := utf8.DecodeRuneInString(s)
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteString(s[:n])
+		}
+		s = s[n:]
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,279 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import "math"
+
+// Pre-define some geospatial operations, whose key is the latitude key
+// and whose value contains the longitude key.
+const (
+	CondOpWithinRadius  = "WithinRadius"
+	CondOpWithinBox     = "WithinBox"
+	CondOpWithinPolygon = "WithinPolygon"
+
+	SortOpDistance = "Distance"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+// Point represents a geographic point by the latitude and longitude in degrees.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Haversine returns the great-circle distance in meters
+// between two points by the haversine formula.
+func Haversine(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dlat, dlng := lat2-lat1, radians(b.Lng-a.Lng)
+
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlng/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func radians(degrees float64) float64 { return degrees * math.Pi / 180 }
+func degrees(radians float64) float64 { return radians * 180 / math.Pi }
+
+// GeoShape is the value of the geospatial condition.
+type GeoShape interface {
+	// Contains reports whether the shape contains the point.
+	Contains(Point) bool
+
+	// BoundingBox returns the minimum box containing the shape.
+	BoundingBox() GeoBox
+}
+
+var (
+	_ GeoShape = GeoRadius{}
+	_ GeoShape = GeoBox{}
+	_ GeoShape = GeoPolygon{}
+)
+
+// GeoRadius is the value of the condition CondOpWithinRadius,
+// which represents a circle on the surface of the earth.
+type GeoRadius struct {
+	LngKey string
+	Center Point
+	Meters float64
+}
+
+// Contains reports whether the distance between the center and the point
+// is not greater than the radius.
+func (r GeoRadius) Contains(p Point) bool {
+	return Haversine(r.Center, p) <= r.Meters
+}
+
+// BoundingBox returns the minimum box containing the circle.
+//
+// If the circle covers a pole, the longitude of the box is [-180, 180].
+// If the circle crosses the antimeridian, Min.Lng is greater than Max.Lng.
+func (r GeoRadius) BoundingBox() GeoBox {
+	d := r.Meters / EarthRadius
+	lat, lng := radians(r.Center.Lat), radians(r.Center.Lng)
+	minLat, maxLat := lat-d, lat+d
+
+	var minLng, maxLng float64
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		dlng := math.Asin(math.Sin(d) / math.Cos(lat))
+		minLng, maxLng = lng-dlng, lng+dlng
+		if minLng < -math.Pi {
+			minLng += 2 * math.Pi
+		}
+		if maxLng > math.Pi {
+			maxLng -= 2 * math.Pi
+		}
+	} else {
+		minLat, maxLat = math.Max(minLat, -math.Pi/2), math.Min(maxLat, math.Pi/2)
+		minLng, maxLng = -math.Pi, math.Pi
+	}
+
+	return GeoBox{
+		LngKey: r.LngKey,
+		Min:    Point{Lat: degrees(minLat), Lng: degrees(minLng)},
+		Max:    Point{Lat: degrees(maxLat), Lng: degrees(maxLng)},
+	}
+}
+
+func (r GeoRadius) scopeKeys(name string) any {
+	r.LngKey = scopeKey(name, r.LngKey)
+	return r
+}
+
+// GeoBox is the value of the condition CondOpWithinBox,
+// which represents a box from the south-west point Min
+// to the north-east point Max.
+//
+// If Min.Lng is greater than Max.Lng, the box crosses the antimeridian.
+type GeoBox struct {
+	LngKey string
+	Min    Point
+	Max    Point
+}
+
+// Contains reports whether the box contains the point.
+func (b GeoBox) Contains(p Point) bool {
+	if p.Lat < b.Min.Lat || p.Lat > b.Max.Lat {
+		return false
+	}
+
+	if b.Min.Lng <= b.Max.Lng {
+		return p.Lng >= b.Min.Lng && p.Lng <= b.Max.Lng
+	}
+	return p.Lng >= b.Min.Lng || p.Lng <= b.Max.Lng
+}
+
+// BoundingBox returns the box itself.
+func (b GeoBox) BoundingBox() GeoBox { return b }
+
+func (b GeoBox) scopeKeys(name string) any {
+	b.LngKey = scopeKey(name, b.LngKey)
+	return b
+}
+
+// GeoPolygon is the value of the condition CondOpWithinPolygon,
+// which represents a polygon by the vertexes.
+//
+// The edges are regarded as the straight lines on the plane
+// of the latitude and longitude, and must not cross the antimeridian.
+type GeoPolygon struct {
+	LngKey string
+	Points []Point
+}
+
+// Contains reports whether the polygon contains the point
+// by the ray casting algorithm.
+func (p GeoPolygon) Contains(point Point) (inside bool) {
+	if len(p.Points) < 3 {
+		return false
+	}
+
+	for i, j := 0, len(p.Points)-1; i < len(p.Points); j, i = i, i+1 {
+		a, b := p.Points[i], p.Points[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lng < (b.Lng-a.Lng)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return
+}
+
+// BoundingBox returns the minimum box containing all the vertexes.
+func (p GeoPolygon) BoundingBox() GeoBox {
+	box := GeoBox{LngKey: p.LngKey}
+	for i, point := range p.Points {
+		if i == 0 {
+			box.Min, box.Max = point, point
+			continue
+		}
+
+		box.Min.Lat, box.Max.Lat = math.Min(box.Min.Lat, point.Lat), math.Max(box.Max.Lat, point.Lat)
+		box.Min.Lng, box.Max.Lng = math.Min(box.Min.Lng, point.Lng), math.Max(box.Max.Lng, point.Lng)
+	}
+	return box
+}
+
+func (p GeoPolygon) scopeKeys(name string) any {
+	p.LngKey = scopeKey(name, p.LngKey)
+	return p
+}
+
+// GeoDistance is the value of the sort operation SortOpDistance.
+type GeoDistance struct {
+	LngKey string
+	Center Point
+	Order  string
+}
+
+// Distance returns the distance in meters between the center and the point.
+func (d GeoDistance) Distance(p Point) float64 {
+	return Haversine(d.Center, p)
+}
+
+func (d GeoDistance) scopeKeys(name string) any {
+	d.LngKey = scopeKey(name, d.LngKey)
+	return d
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// WithinRadius returns a new condition that the point of the latitude
+// and longitude keys is within the radius in meters around the center.
+func WithinRadius(latKey, lngKey string, center Point, meters float64) Condition {
+	return New(CondOpWithinRadius, latKey, GeoRadius{LngKey: lngKey, Center: center, Meters: meters}).Condition()
+}
+
+// WithinBox returns a new condition that the point of the latitude
+// and longitude keys is within the box from the south-west point
+// to the north-east point.
+func WithinBox(latKey, lngKey string, southWest, northEast Point) Condition {
+	return New(CondOpWithinBox, latKey, GeoBox{LngKey: lngKey, Min: southWest, Max: northEast}).Condition()
+}
+
+// WithinPolygon returns a new condition that the point of the latitude
+// and longitude keys is within the polygon.
+func WithinPolygon(latKey, lngKey string, points ...Point) Condition {
+	return New(CondOpWithinPolygon, latKey, GeoPolygon{LngKey: lngKey, Points: points}).Condition()
+}
+
+// DistanceFrom returns a new sorter by the distance between the center
+// and the point of the latitude and longitude keys.
+//
+// order may be SortAsc or SortDesc.
+func DistanceFrom(latKey, lngKey string, center Point, order string) Sorter {
+	return New(SortOpDistance, latKey, GeoDistance{LngKey: lngKey, Center: center, Order: order}).Sorter()
+}
+
+// GeoPrefilter returns the range conditions on the latitude
+// and longitude keys by the bounding box of the geospatial condition,
+// which can use the indexes to pre-filter the points before evaluating
+// the exact geospatial condition. For example,
+//
+//	WithinRadius("lat", "lng", Point{Lat: 30, Lng: 120}, 1000)
+//	=> And(Between("lat", 29.991, 30.009), Between("lng", 119.990, 120.010))
+//
+// If the box crosses the antimeridian, the longitude condition is
+// Or(GreaterEqual(lngKey, min), LessEqual(lngKey, max)). If the box covers
+// all the longitudes, there is only the latitude condition.
+//
+// If c is not a geospatial condition, return (nil, false).
+func GeoPrefilter(c Condition) (Condition, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	shape, ok := o.Val.(GeoShape)
+	if !ok {
+		return nil, false
+	}
+
+	box := shape.BoundingBox()
+	lat := Key(o.Key).Between(box.Min.Lat, box.Max.Lat)
+	lng := Key(box.LngKey)
+	switch {
+	case box.Min.Lng <= -180 && box.Max.Lng >= 180:
+		return lat, true
+	case box.Min.Lng <= box.Max.Lng:
+		return And(lat, lng.Between(box.Min.Lng, box.Max.Lng)), true
+	default:
+		return And(lat, Or(lng.GreaterEqual(box.Min.Lng), lng.LessEqual(box.Max.Lng))), true
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	// Paris to London is about 343.5km.
+	paris, london := Point{Lat: 48.8566, Lng: 2.3522}, Point{Lat: 51.5074, Lng: -0.1278}
+	if d := Haversine(paris, london); math.Abs(d-343_500) > 1000 {
+		t.Errorf("unexpected distance %v", d)
+	}
+
+	if d := Haversine(paris, paris); d != 0 {
+		t.Errorf("expect the distance 0, but got %v", d)
+	}
+}
+
+func TestGeoShape(t *testing.T) {
+	center := Point{Lat: 30, Lng: 120}
+	radius := WithinRadius(KeyLat.Key, KeyLong.Key, center, 1000).Op().Val.(GeoShape)
+	if !radius.Contains(Point{Lat: 30.005, Lng: 120.005}) {
+		t.Errorf("expect containing the point about 727m away")
+	}
+	if radius.Contains(Point{Lat: 30.01, Lng: 120}) {
+		t.Errorf("unexpect containing the point about 1112m away")
+	}
+
+	box := radius.BoundingBox()
+	if math.Abs(box.Min.Lat-29.991) > 0.001 || math.Abs(box.Max.Lat-30.009) > 0.001 ||
+		math.Abs(box.Min.Lng-119.990) > 0.001 || math.Abs(box.Max.Lng-120.010) > 0.001 {
+		t.Errorf("unexpected bounding box %+v", box)
+	}
+
+	// Cross the antimeridian.
+	box = GeoRadius{Center: Point{Lat: 0, Lng: 179.999}, Meters: 1000}.BoundingBox()
+	if box.Min.Lng < 179 || box.Max.Lng > -179 || !box.Contains(Point{Lat: 0, Lng: -179.999}) {
+		t.Errorf("unexpected bounding box %+v", box)
+	}
+
+	// Cover the north pole.
+	box = GeoRadius{Center: Point{Lat: 89.999, Lng: 0}, Meters: 1000}.BoundingBox()
+	if box.Max.Lat != 90 || box.Min.Lng != -180 || box.Max.Lng != 180 {
+		t.Errorf("unexpected bounding box %+v", box)
+	}
+
+	polygon := WithinPolygon("lat", "lng", Point{0, 0}, Point{0, 10}, Point{10, 10}, Point{10, 0}).Op().Val.(GeoShape)
+	if !polygon.Contains(Point{5, 5}) || polygon.Contains(Point{5, 11}) || polygon.Contains(Point{-1, 5}) {
+		t.Errorf("unexpected polygon result")
+	}
+	if box := polygon.BoundingBox(); box.Min != (Point{0, 0}) || box.Max != (Point{10, 10}) {
+		t.Errorf("unexpected bounding box %+v", box)
+	}
+}
+
+func TestGeoPrefilter(t *testing.T) {
+	for i, c := range []struct {
+		cond   Condition
+		expect string
+	}{
+		{
+			WithinBox("lat", "lng", Point{1, 2}, Point{3, 4}).Scope("t"),
+			"And(Between(t.lat, 1, 3), Between(t.lng, 2, 4))",
+		},
+		{
+			WithinBox("lat", "lng", Point{1, 170}, Point{3, -170}),
+			"And(Between(lat, 1, 3), Or(GreaterEqual(lng, 170), LessEqual(lng, -170)))",
+		},
+		{
+			WithinRadius("lat", "lng", Point{90, 0}, 1000),
+			"Between(lat, 89.99100679636277, 90)",
+		},
+		{Equal("lat", 1), ""},
+	} {
+		prefilter, ok := GeoPrefilter(c.cond)
+		if s := formatGeoCond(prefilter); ok != (c.expect != "") || s != c.expect {
+			t.Errorf("%d: expect '%s', but got '%s'", i, c.expect, s)
+		}
+	}
+
+	sorter := DistanceFrom("lat", "lng", Point{1, 2}, SortAsc).Op().Scope("t")
+	if d := sorter.Val.(GeoDistance); sorter.Key != "t.lat" || d.LngKey != "t.lng" || d.Distance(Point{1, 2}) != 0 {
+		t.Errorf("unexpected sorter %+v", sorter)
+	}
+}
+
+func formatGeoCond(c Condition) string {
+	if c == nil {
+		return ""
+	}
+
+	o := c.Op()
+	switch v := o.Val.(type) {
+	case []Condition:
+		s := o.Op + "("
+		for i, cond := range v {
+			if i > 0 {
+				s += ", "
+			}
+			s += formatGeoCond(cond)
+		}
+		return s + ")"
+
+	case Boundary:
+		return o.Op + "(" + o.Key + ", " + formatFloat(v.Lower) + ", " + formatFloat(v.Upper) + ")"
+
+	default:
+		return o.Op + "(" + o.Key + ", " + formatFloat(v) + ")"
+	}
+}
+
+func formatFloat(v any) string {
+	return strconv.FormatFloat(v.(float64), 'g', -1, 64)
+}
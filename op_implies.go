@@ -0,0 +1,100 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Implies reports whether the condition a implies b, that's, every object
+// satisfying a also satisfies b, or the result of a is the subset of b.
+// For example, the result of a new query can be filtered in memory
+// from the cached result of b if Implies(a, b) returns true.
+//
+// It reasons about the ranges, such as Less, Greater and Between, the sets,
+// such as Equal, NotEqual, In and NotIn, IsNull and IsNotNull of the same key,
+// and the structures of And, Or and Not. Null does not satisfy the comparisons
+// as the three-valued logic of SQL, so Not(Greater("a", 1)) is equal to
+// LessEqual("a", 1). The values of the same key must be comparable,
+// such as the numbers, strings, bools and time.Time. Other conditions,
+// such as Like and EqualKey, imply only the same conditions.
+//
+// nil is regarded as the always-true condition.
+//
+// If it cannot be decided, return false conservatively.
+// If the condition is invalid, such as Between without Boundary, return an error.
+func Implies(a, b Condition) (bool, error) {
+	var c comparator
+	na, err := c.newCondNode(a, false)
+	if err != nil {
+		return false, fmt.Errorf("op.Implies: %w", err)
+	}
+
+	nb, err := c.newCondNode(b, false)
+	if err != nil {
+		return false, fmt.Errorf("op.Implies: %w", err)
+	}
+
+	ok := c.implies(na, nb)
+	return ok && !c.failed, nil
+}
+
+func (c *comparator) implies(a, b condNode) bool {
+	switch {
+	case b.op == CondOpAnd:
+		for _, sub := range b.nodes {
+			if !c.implies(a, sub) {
+				return false
+			}
+		}
+		return true
+
+	case a.op == CondOpOr:
+		for _, sub := range a.nodes {
+			if !c.implies(sub, b) {
+				return false
+			}
+		}
+		return true
+
+	case c.unsatisfiable(a):
+		return true
+	}
+
+	if key, ok := b.singleKey(); ok && c.subset(c.keyDomain(a, key), c.keyDomain(b, key)) {
+		return true
+	}
+
+	if b.op == CondOpOr {
+		for _, sub := range b.nodes {
+			if c.implies(a, sub) {
+				return true
+			}
+		}
+	}
+
+	if a.op == CondOpAnd {
+		for _, sub := range a.nodes {
+			if c.implies(sub, b) {
+				return true
+			}
+		}
+	}
+
+	return a.op == "" && b.op == "" && !a.known && !b.known &&
+		a.negated == b.negated && a.atom.Op == b.atom.Op && a.atom.Key == b.atom.Key &&
+		reflect.DeepEqual(a.atom.Val, b.atom.Val)
+}
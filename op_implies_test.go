@@ -0,0 +1,72 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImplies(t *testing.T) {
+	now := time.Now()
+	for i, c := range []struct {
+		a, b   Condition
+		expect bool
+	}{
+		{Gt("age", 30), Gt("age", 18), true},
+		{Gt("age", 18), Gt("age", 30), false},
+		{GtEq("age", 18), Gt("age", 18), false},
+		{Gt("age", 18), GtEq("age", 18.0), true},
+		{Between("age", 20, 30), And(Gt("age", 18), Le("age", 31)), true},
+		{Between("age", 20, 30), Between("age", 10, 30), true},
+		{Between("age", 20, 30), NotBetween("age", 31, 40), true},
+		{Eq("age", 20), In("age", []int{10, 20}), true},
+		{In("age", []int{10, 20}), Or(Eq("age", 10), Eq("age", 20)), true},
+		{In("age", []int{10, 20}), Or(Eq("age", 10), Eq("age", 30)), false},
+		{In("age", []int{10, 20}), NotIn("age", []int{15, 30}), true},
+		{In("age", []int{10, 20}), NotEq("age", 10), false},
+		{NotIn("age", []int{1, 2}), NotEq("age", 1), true},
+		{Gt("age", 1), IsNotNull("age"), true},
+		{IsNotNull("age"), Gt("age", 1), false},
+		{IsNull("age"), Not(Gt("age", 1)), false},
+		{Not(Gt("age", 1)), LeEq("age", 1), true},
+		{Not(Or(Gt("age", 1), IsNull("age"))), LeEq("age", 1), true},
+		{And(Eq("status", "active"), Gt("age", 18)), Eq("status", "active"), true},
+		{And(Eq("status", "active"), Gt("age", 18)), Or(Eq("status", "active"), Eq("id", 1)), true},
+		{Or(Eq("status", "a"), Eq("status", "b")), In("status", []string{"a", "b", "c"}), true},
+		{Or(Eq("status", "a"), Eq("id", 1)), Eq("status", "a"), false},
+		{And(Gt("age", 30), Le("age", 20)), Eq("id", 1), true},
+		{Le("created_at", now), Le("created_at", now.Add(time.Hour)), true},
+		{Like("name", "a%"), Like("name", "a%"), true},
+		{And(Like("name", "a%"), Eq("id", 1)), Like("name", "a%"), true},
+		{Like("name", "a%"), Like("name", "b%"), false},
+		{Not(Like("name", "a%")), NotLike("name", "a%"), true},
+		{Gt("age", "18"), Gt("age", 10), false},
+		{Eq("a", 1), nil, true},
+		{nil, Eq("a", 1), false},
+		{nil, Or(IsNull("a"), IsNotNull("a")), true},
+		{Or(), Eq("a", 1), true},
+	} {
+		if ok, err := Implies(c.a, c.b); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if ok != c.expect {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, ok)
+		}
+	}
+
+	if _, err := Implies(New(CondOpBetween, "a", 1).Condition(), nil); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
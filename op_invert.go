@@ -0,0 +1,157 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xgfone/go-op/internal/strcase"
+)
+
+// ErrNotInvertible represents that the updater cannot be inverted.
+var ErrNotInvertible = errors.New("not invertible")
+
+// Invert returns the updaters to restore the state before ups are applied,
+// which are in the reverse order of ups, and the batch updaters are flattened.
+//
+// The updaters by the numeric value are inverted directly as follow:
+//
+//	Inc    <=> Dec
+//	Add(v) <=> Sub(v)
+//	Mul(v) <=> Div(v), but v must be a non-zero float
+//
+// Since the integer division truncates, such as x/3*3 != x,
+// Mul and Div by the integer are inverted by the before value, like below.
+//
+// Other updaters, including the ones based on the other key, such as AddKey,
+// are inverted to Set(key, value), and value is got by the key from before,
+// which is the captured state before updating, and may be a struct or a map
+// with the string key, or a pointer to them. For the struct, the key is got
+// from the tag DefaultTag, which is the same as Diff. For the map, the key
+// may be the scoped key, such as "a.b", or the nested maps.
+//
+// If the updater cannot be inverted, return an error wrapping ErrNotInvertible.
+func Invert(ups []Updater, before any) ([]Updater, error) {
+	ups = flattenUpdaters(ups)
+	results := make([]Updater, 0, len(ups))
+	for i := len(ups) - 1; i >= 0; i-- {
+		up, err := invertUpdater(ups[i].Op(), before)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, up)
+	}
+	return results, nil
+}
+
+func invertUpdater(op Op, before any) (Updater, error) {
+	switch op.Op {
+	case UpdateOpInc:
+		return op.WithOp(UpdateOpDec).Updater(), nil
+
+	case UpdateOpDec:
+		return op.WithOp(UpdateOpInc).Updater(), nil
+
+	case UpdateOpAdd, UpdateOpSub:
+		if _, ok := toNumber(op.Val); ok {
+			if op.Op == UpdateOpAdd {
+				return op.WithOp(UpdateOpSub).Updater(), nil
+			}
+			return op.WithOp(UpdateOpAdd).Updater(), nil
+		}
+
+	case UpdateOpMul, UpdateOpDiv:
+		if n, ok := toNumber(op.Val); ok && n.IsFloat {
+			if n.sign() == 0 {
+				return nil, fmt.Errorf("op.Invert: %s by zero on key '%s': %w", op.Op, op.Key, ErrNotInvertible)
+			}
+
+			if op.Op == UpdateOpMul {
+				return op.WithOp(UpdateOpDiv).Updater(), nil
+			}
+			return op.WithOp(UpdateOpMul).Updater(), nil
+		}
+	}
+
+	if op.Key == "" {
+		return nil, fmt.Errorf("op.Invert: %s without key: %w", op.Op, ErrNotInvertible)
+	}
+
+	value, ok := lookupValue(reflect.ValueOf(before), op.Key)
+	if !ok {
+		return nil, fmt.Errorf("op.Invert: %s on key '%s' without the before value: %w",
+			op.Op, op.Key, ErrNotInvertible)
+	}
+	return New(UpdateOpSet, op.Key, value).WithTags(op.Tags).Updater(), nil
+}
+
+// lookupValue looks up the value by the key from the struct or map.
+func lookupValue(v reflect.Value, key string) (value any, ok bool) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+
+		ktype := v.Type().Key()
+		if mv := v.MapIndex(reflect.ValueOf(key).Convert(ktype)); mv.IsValid() {
+			return valueInterface(mv), true
+		}
+
+		if name, sub, found := strings.Cut(key, Sep); found {
+			if mv := v.MapIndex(reflect.ValueOf(name).Convert(ktype)); mv.IsValid() {
+				return lookupValue(mv, sub)
+			}
+		}
+
+	case reflect.Struct:
+		vtype := v.Type()
+		for i, _len := 0, vtype.NumField(); i < _len; i++ {
+			sf := vtype.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+
+			name, ok := getFieldKey(sf, DefaultTag)
+			switch {
+			case !ok:
+				continue
+
+			case sf.Anonymous && name == "":
+				if value, ok := lookupValue(v.Field(i), key); ok {
+					return value, true
+				}
+				continue
+
+			case name == "":
+				name = strcase.Snake(sf.Name)
+			}
+
+			if name == key {
+				return valueInterface(v.Field(i)), true
+			} else if sub := strings.TrimPrefix(key, name+Sep); len(sub) < len(key) {
+				if value, ok := lookupValue(v.Field(i), sub); ok {
+					return value, true
+				}
+			}
+		}
+	}
+
+	return
+}
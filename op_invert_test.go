@@ -0,0 +1,75 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	type Addr struct {
+		City string `sql:"city"`
+	}
+
+	type User struct {
+		Name string `sql:"name"`
+		Addr Addr   `sql:"addr"`
+	}
+
+	before := &User{Name: "a", Addr: Addr{City: "Beijing"}}
+	ups := []Updater{
+		Inc("count"), Batch(Add("score", 10), Mul("rate", 2.0)), Set("name", "b"),
+		Set("addr.city", "Shanghai"), Key("total").AddKey("score", 1),
+	}
+
+	_, err := Invert(ups, before)
+	if !errors.Is(err, ErrNotInvertible) {
+		t.Errorf("expect ErrNotInvertible, but got %v", err)
+	}
+
+	results, err := Invert(ups[:4], before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []Updater{
+		Set("addr.city", "Beijing"), Set("name", "a"), Div("rate", 2.0), Sub("score", 10), Dec("count"),
+	}
+	if !reflect.DeepEqual(results, expects) {
+		t.Errorf("expect %v, but got %v", expects, results)
+	}
+
+	results, err = Invert([]Updater{Key("total").AddKey("score", 1)}, map[string]any{"total": 5})
+	if err != nil {
+		t.Fatal(err)
+	} else if expects := []Updater{Set("total", 5)}; !reflect.DeepEqual(results, expects) {
+		t.Errorf("expect %v, but got %v", expects, results)
+	}
+
+	results, err = Invert([]Updater{Div("n", 3), Mul("m", 3)}, map[string]any{"n": 10, "m": 4})
+	if err != nil {
+		t.Fatal(err)
+	} else if expects := []Updater{Set("m", 4), Set("n", 10)}; !reflect.DeepEqual(results, expects) {
+		t.Errorf("expect %v, but got %v", expects, results)
+	}
+
+	for i, up := range []Updater{Mul("rate", 0.0), Mul("rate", 0), Div("n", 3), Mul("n", 3)} {
+		if _, err = Invert([]Updater{up}, nil); !errors.Is(err, ErrNotInvertible) {
+			t.Errorf("%d: expect ErrNotInvertible, but got %v", i, err)
+		}
+	}
+}
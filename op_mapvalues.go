@@ -0,0 +1,271 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"sync"
+)
+
+// mapValues returns a new operation by replacing the values
+// of the operation and its sub-operations with the results of f,
+// including the elements of the slices, arrays and maps, the pointed values,
+// and the exported fields of the structs, such as Boundary.
+//
+// Only the values whose types may contain Param, Expr, RelTime or Oper
+// are visited, so the scalar elements, such as those of []string,
+// are skipped without being boxed.
+//
+// f is called with each visited non-nil value before its elements.
+// If f returns false, the value is kept and its elements are mapped in turn.
+//
+// If a replaced element cannot be assigned to the slice, array or map,
+// it is rebuilt as []any or map[K]any. If a replaced struct field or pointed
+// value cannot be assigned, it is kept.
+//
+// The original operation is not modified. If nothing is replaced,
+// return the original operation.
+func mapValues[T Oper](o T, f func(any) (any, bool)) T {
+	if Oper(o) == nil {
+		return o
+	}
+
+	if oper, ok := mapOperValues(o, f); ok {
+		if result, ok := oper.(T); ok {
+			return result
+		}
+	}
+	return o
+}
+
+func mapOperValues(o Oper, f func(any) (any, bool)) (Oper, bool) {
+	op := o.Op()
+	value, ok := mapValue(reflect.ValueOf(op.Val), f)
+	if !ok {
+		return o, false
+	}
+
+	if value.IsValid() {
+		op.Val = value.Interface()
+	} else {
+		op.Val = nil
+	}
+
+	switch o.(type) {
+	case Condition:
+		return op.Condition(), true
+	case Updater:
+		return op.Updater(), true
+	case Sorter:
+		return op.Sorter(), true
+	case Pagination:
+		return op.Pagination(), true
+	default:
+		return op.Oper(), true
+	}
+}
+
+func mapValue(v reflect.Value, f func(any) (any, bool)) (reflect.Value, bool) {
+	if !v.IsValid() || !mayContainValue(v.Type()) {
+		return v, false
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return v, false
+		}
+	}
+
+	if v.Kind() == reflect.Interface {
+		return mapValue(v.Elem(), f)
+	}
+
+	if v.CanInterface() {
+		i := v.Interface()
+		if result, ok := f(i); ok {
+			return reflect.ValueOf(result), true
+		}
+
+		if o, ok := i.(Oper); ok {
+			if o, ok := mapOperValues(o, f); ok {
+				return reflect.ValueOf(o), true
+			}
+			return v, false
+		}
+	}
+
+	var result reflect.Value
+	switch v.Kind() {
+	case reflect.Ptr:
+		if elem, ok := mapValue(v.Elem(), f); ok && isAssignable(elem, v.Type().Elem()) {
+			result = reflect.New(v.Type().Elem())
+			setValue(result.Elem(), elem)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i, _len := 0, v.Len(); i < _len; i++ {
+			elem, ok := mapValue(v.Index(i), f)
+			if !ok {
+				continue
+			}
+
+			if !result.IsValid() {
+				if v.Kind() == reflect.Slice {
+					result = reflect.MakeSlice(v.Type(), _len, _len)
+					reflect.Copy(result, v)
+				} else {
+					result = reflect.New(v.Type()).Elem()
+					result.Set(v)
+				}
+			}
+
+			if !isAssignable(elem, result.Type().Elem()) {
+				anys := reflect.MakeSlice(reflect.SliceOf(anyType), _len, _len)
+				for j := 0; j < _len; j++ {
+					anys.Index(j).Set(result.Index(j))
+				}
+				result = anys
+			}
+			setValue(result.Index(i), elem)
+		}
+
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			elem, ok := mapValue(iter.Value(), f)
+			if !ok {
+				continue
+			}
+
+			if !result.IsValid() {
+				result = copyMap(v, v.Type())
+			}
+			if !isAssignable(elem, result.Type().Elem()) {
+				result = copyMap(result, reflect.MapOf(v.Type().Key(), anyType))
+			}
+
+			value := reflect.New(result.Type().Elem()).Elem()
+			setValue(value, elem)
+			result.SetMapIndex(iter.Key(), value)
+		}
+
+	case reflect.Struct:
+		vtype := v.Type()
+		for i, _len := 0, vtype.NumField(); i < _len; i++ {
+			if !vtype.Field(i).IsExported() {
+				continue
+			}
+
+			field, ok := mapValue(v.Field(i), f)
+			if !ok || !isAssignable(field, vtype.Field(i).Type) {
+				continue
+			}
+
+			if !result.IsValid() {
+				result = reflect.New(vtype).Elem()
+				result.Set(v)
+			}
+			setValue(result.Field(i), field)
+		}
+	}
+
+	return result, result.IsValid()
+}
+
+// copyMap returns a new map of type t with the elements of the map src.
+func copyMap(src reflect.Value, t reflect.Type) reflect.Value {
+	dst := reflect.MakeMapWithSize(t, src.Len())
+	for iter := src.MapRange(); iter.Next(); {
+		dst.SetMapIndex(iter.Key(), iter.Value())
+	}
+	return dst
+}
+
+// isAssignable reports whether src can be assigned to the value of type t.
+// The invalid src, that is, nil, can be assigned to the nillable type.
+func isAssignable(src reflect.Value, t reflect.Type) bool {
+	if src.IsValid() {
+		return src.Type().AssignableTo(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return true
+	default:
+		return false
+	}
+}
+
+// setValue sets dst to src, or the zero value if src is invalid,
+// which must be assignable.
+func setValue(dst, src reflect.Value) {
+	if src.IsValid() {
+		dst.Set(src)
+	} else {
+		dst.Set(reflect.Zero(dst.Type()))
+	}
+}
+
+var (
+	paramType   = reflect.TypeOf(Param(""))
+	exprType    = reflect.TypeOf(Expr{})
+	relTimeType = reflect.TypeOf(RelTime{})
+	operType    = reflect.TypeOf((*Oper)(nil)).Elem()
+	anyType     = reflect.TypeOf((*any)(nil)).Elem()
+
+	valueTypes sync.Map // map[reflect.Type]bool
+)
+
+// mayContainValue reports whether the value of the type may be or contain
+// Param, Expr, RelTime or Oper, which are the values mapped by mapValues.
+func mayContainValue(t reflect.Type) bool {
+	if v, ok := valueTypes.Load(t); ok {
+		return v.(bool)
+	}
+
+	may := mayContain(t, make(map[reflect.Type]struct{}))
+	valueTypes.Store(t, may)
+	return may
+}
+
+func mayContain(t reflect.Type, visiting map[reflect.Type]struct{}) bool {
+	switch t {
+	case paramType, exprType, relTimeType:
+		return true
+	}
+
+	if t.Kind() == reflect.Interface || t.Implements(operType) {
+		return true
+	}
+
+	if _, ok := visiting[t]; ok { // Recursive type
+		return false
+	}
+	visiting[t] = struct{}{}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return mayContain(t.Elem(), visiting)
+
+	case reflect.Struct:
+		for i, _len := 0, t.NumField(); i < _len; i++ {
+			if field := t.Field(i); field.IsExported() && mayContain(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
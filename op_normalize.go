@@ -0,0 +1,250 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConflictError represents that there are the conflicting updaters
+// on the same keys, which cannot be merged.
+type ConflictError struct {
+	Keys []string
+}
+
+// Error implements the interface error.
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("conflicting updates on the keys: %s", strings.Join(e.Keys, ", "))
+}
+
+// NormalizeUpdaters flattens the nested batch updaters, and merges
+// the updaters on the same key into one, which are ordered by the first
+// occurrence of the key. The updater alone on its key is kept as it is.
+//
+// The rules to merge the updaters on the same key are as follow:
+//
+//	Inc, Dec, Add and Sub by numbers: Add or Sub by the sum, such as
+//	    Inc+Inc => Add(2), Add(3)+Sub(1) => Add(2), Add(1)+Sub(1) => (dropped)
+//	Mul by numbers:                  Mul by the product
+//	Div by numbers:                  Div by the product
+//	Min or Max by numbers:           Min by the minimum, or Max by the maximum
+//	Concat by strings:               Concat by the concatenation
+//	Push, Pull and AddToSet:         same as MergeArrayUpdaters
+//	Set, Unset, SetIfNull, SetCase, Min, Max and Mod by the equal values:
+//	                                 the first one
+//
+// For other cases, such as Set(k, 1)+Set(k, 2) or Inc+Set, the updaters
+// conflict, and return a ConflictError containing all the conflicting keys.
+//
+// The updaters without the key, such as Upsert, are kept as they are.
+func NormalizeUpdaters(ups ...Updater) ([]Updater, error) {
+	ups = flattenUpdaters(ups)
+
+	groups := make([][]Updater, 0, len(ups))
+	indexes := make(map[string]int, len(ups))
+	for _, up := range ups {
+		key := up.Op().Key
+		if key == "" { // Such as Upsert
+			groups = append(groups, []Updater{up})
+			continue
+		}
+
+		if index, ok := indexes[key]; ok {
+			groups[index] = append(groups[index], up)
+		} else {
+			indexes[key] = len(groups)
+			groups = append(groups, []Updater{up})
+		}
+	}
+
+	var conflicts []string
+	results := make([]Updater, 0, len(groups))
+	for _, group := range groups {
+		key := group[0].Op().Key
+		if len(group) == 1 {
+			results = append(results, group[0])
+			continue
+		}
+
+		up, ok := mergeUpdaters(group)
+		switch {
+		case !ok:
+			conflicts = append(conflicts, key)
+		case up != nil:
+			results = append(results, up)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, ConflictError{Keys: conflicts}
+	}
+	return results, nil
+}
+
+// mergeUpdaters merges the updaters on the same key.
+//
+// If the merged updater is a no-op, return (nil, true).
+func mergeUpdaters(ups []Updater) (up Updater, ok bool) {
+	first := ups[0].Op()
+	switch first.Op {
+	case UpdateOpInc, UpdateOpDec, UpdateOpAdd, UpdateOpSub:
+		return mergeAdditiveUpdaters(first, ups)
+
+	case UpdateOpMul, UpdateOpDiv:
+		if up, ok = mergeNumberUpdaters(first, ups, number.mul); ok {
+			return
+		}
+
+	case UpdateOpMin:
+		if up, ok = mergeNumberUpdaters(first, ups, minNumber); ok {
+			return
+		}
+
+	case UpdateOpMax:
+		if up, ok = mergeNumberUpdaters(first, ups, maxNumber); ok {
+			return
+		}
+
+	case UpdateOpConcat:
+		if up, ok = mergeConcatUpdaters(first, ups); ok {
+			return
+		}
+
+	case UpdateOpPush, UpdateOpPull, UpdateOpAddToSet:
+		value := first.Val
+		for _, up := range ups[1:] {
+			op := up.Op()
+			if op.Op != first.Op {
+				return nil, false
+			}
+
+			if value, ok = mergeArrayValues(op.Op, value, op.Val); !ok {
+				return nil, false
+			}
+		}
+		return first.WithValue(value).Updater(), true
+	}
+
+	switch first.Op {
+	case UpdateOpSet, UpdateOpUnset, UpdateOpSetIfNull, UpdateOpSetCase,
+		UpdateOpMin, UpdateOpMax, UpdateOpMod:
+		for _, up := range ups[1:] {
+			if op := up.Op(); op.Op != first.Op || !reflect.DeepEqual(op.Val, first.Val) {
+				return nil, false
+			}
+		}
+		return ups[0], true
+	}
+
+	return nil, false
+}
+
+func mergeAdditiveUpdaters(first Op, ups []Updater) (Updater, bool) {
+	values := make([]any, 0, len(ups))
+	var sum number
+	for _, up := range ups {
+		switch op := up.Op(); op.Op {
+		case UpdateOpInc:
+			sum = sum.add(intNumber(1))
+
+		case UpdateOpDec:
+			sum = sum.add(intNumber(-1))
+
+		case UpdateOpAdd, UpdateOpSub:
+			n, ok := toNumber(op.Val)
+			if !ok {
+				return nil, false
+			}
+
+			if op.Op == UpdateOpSub {
+				n = n.neg()
+			}
+
+			sum = sum.add(n)
+			values = append(values, op.Val)
+
+		default:
+			return nil, false
+		}
+	}
+
+	value := sum.abs().value(commonType(values...))
+	switch sum.sign() {
+	case 1:
+		return first.WithOp(UpdateOpAdd).WithValue(value).Updater(), true
+	case -1:
+		return first.WithOp(UpdateOpSub).WithValue(value).Updater(), true
+	default:
+		return nil, true
+	}
+}
+
+func mergeNumberUpdaters(first Op, ups []Updater, merge func(number, number) number) (Updater, bool) {
+	values := make([]any, 0, len(ups))
+	var result number
+	for i, up := range ups {
+		op := up.Op()
+		if op.Op != first.Op {
+			return nil, false
+		}
+
+		n, ok := toNumber(op.Val)
+		if !ok {
+			return nil, false
+		}
+
+		if i == 0 {
+			result = n
+		} else {
+			result = merge(result, n)
+		}
+		values = append(values, op.Val)
+	}
+
+	return first.WithValue(result.value(commonType(values...))).Updater(), true
+}
+
+func mergeConcatUpdaters(first Op, ups []Updater) (Updater, bool) {
+	var b strings.Builder
+	for _, up := range ups {
+		op := up.Op()
+		if op.Op != first.Op {
+			return nil, false
+		}
+
+		s, ok := op.Val.(string)
+		if !ok {
+			return nil, false
+		}
+		b.WriteString(s)
+	}
+	return first.WithValue(b.String()).Updater(), true
+}
+
+func minNumber(a, b number) number {
+	if a.compare(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func maxNumber(a, b number) number {
+	if a.compare(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeUpdaters(t *testing.T) {
+	ups, err := NormalizeUpdaters(
+		Inc("a"), Set("name", "x"), Batch(Inc("a"), Add("b", 3)), Sub("b", 1),
+		Add("c", 1), Sub("c", 1), Mul("d", 2.0), Mul("d", 1.5), Max("e", 3), Max("e", 5),
+		Set("name", "x"), Push("tags", "t1"), Push("tags", "t2"), Sub("f", uint(1)), Dec("f"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []Updater{
+		Add("a", 2), Set("name", "x"), Add("b", 2), Mul("d", 3.0), Max("e", 5),
+		Push("tags", "t1", "t2"), Sub("f", uint(2)),
+	}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	_, err = NormalizeUpdaters(Set("a", 1), Set("a", 2), Inc("b"), Set("b", 1), Set("c", 1))
+	var cerr ConflictError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expect a ConflictError, but got %v", err)
+	} else if expect := []string{"a", "b"}; !reflect.DeepEqual(cerr.Keys, expect) {
+		t.Errorf("expect conflicting keys %v, but got %v", expect, cerr.Keys)
+	}
+
+	if ups, err = NormalizeUpdaters(Add("a", int64(math.MaxInt64)), Inc("a")); err != nil {
+		t.Error(err)
+	} else if expects := []Updater{Add("a", float64(math.MaxInt64)+1)}; !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	up1 := Upsert([]Updater{Set("id", 1)}, []string{"id"})
+	up2 := Upsert([]Updater{Set("id", 2)}, []string{"id"})
+	if ups, err = NormalizeUpdaters(up1, Inc("a"), up2); err != nil {
+		t.Error(err)
+	} else if expects := []Updater{up1, Inc("a"), up2}; !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+}
@@ -18,7 +18,8 @@ package op
 const (
 	KindPagination = "Pagination"
 
-	PaginationOpPageSize = "PageSize"
+	PaginationOpPageSize    = "PageSize"
+	PaginationOpOffsetLimit = "OffsetLimit"
 )
 
 // Limiter represents a number limiter of the objects.
@@ -73,3 +74,21 @@ func (p PageSizer) Limit() int { return int(p.Size) }
 func PageSize(page, size int64) Pagination {
 	return New(PaginationOpPageSize, "", PageSizer{Page: page, Size: size}).Pagination()
 }
+
+/// ---------------------------------------------------------------------- ///
+
+// OffsetLimiter is a pagination based on offset and limit.
+type OffsetLimiter struct {
+	Offset int64 // Start with 0
+	Size   int64 // 0 means no limit
+}
+
+// Limit implements the interface Limiter.
+func (p OffsetLimiter) Limit() int { return int(p.Size) }
+
+// OffsetLimit is used to new a Pagination based on offset and limit.
+//
+// The key is empty, and the value is a OffsetLimiter instance.
+func OffsetLimit(offset, limit int64) Pagination {
+	return New(PaginationOpOffsetLimit, "", OffsetLimiter{Offset: offset, Size: limit}).Pagination()
+}
@@ -33,3 +33,9 @@ func TestPageSize(t *testing.T) {
 		t.Errorf("expect limit %d, but got %d", 20, limit)
 	}
 }
+
+func TestOffsetLimit(t *testing.T) {
+	if limit := GetLimitFromPagination(OffsetLimit(10, 20)); limit != 20 {
+		t.Errorf("expect limit %d, but got %d", 20, limit)
+	}
+}
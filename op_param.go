@@ -0,0 +1,179 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"sort"
+	"strings"
+)
+
+// Param is a named placeholder of the value, which is bound
+// to the actual value later by Bind. So the operation can be built once
+// as the template and bound with the different values for each request.
+//
+// It can be used anywhere the value goes, such as
+//
+//	Equal("user_id", Param("uid"))
+//	Key("status").In(Param("statuses"))
+//	In("status", []any{Param("status1"), Param("status2")})
+//	Between("age", Param("min"), Param("max"))
+type Param string
+
+// BindError represents the mismatch between the params
+// in the operation and the given values.
+type BindError struct {
+	Missing []string // The params without the values.
+	Unused  []string // The values without the params.
+	Unbound []string // The params whose values cannot be assigned.
+}
+
+// Error implements the interface error.
+func (e BindError) Error() string {
+	var b strings.Builder
+	if len(e.Missing) > 0 {
+		b.WriteString("missing params: ")
+		b.WriteString(strings.Join(e.Missing, ", "))
+	}
+
+	if len(e.Unused) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString("unused params: ")
+		b.WriteString(strings.Join(e.Unused, ", "))
+	}
+
+	if len(e.Unbound) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString("unbound params: ")
+		b.WriteString(strings.Join(e.Unbound, ", "))
+	}
+
+	return b.String()
+}
+
+// Params returns the sorted names of the unbound params
+// in the operation and its sub-operations.
+func Params(o Oper) []string {
+	params := make(map[string]struct{})
+	mapValues(o, func(v any) (any, bool) {
+		if p, ok := v.(Param); ok {
+			params[string(p)] = struct{}{}
+		}
+		return nil, false
+	})
+	return sortedNames(params)
+}
+
+func sortedNames(names map[string]struct{}) []string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	results := make([]string, 0, len(names))
+	for name := range names {
+		results = append(results, name)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// HasParams reports whether the operation or its sub-operations
+// contain any unbound param.
+func HasParams(o Oper) bool { return len(Params(o)) > 0 }
+
+// CheckParams returns a BindError containing the missing params
+// if the operation contains any unbound param. Or, return nil.
+//
+// It is used by the builders to reject the unbound operation.
+func CheckParams(o Oper) error {
+	if params := Params(o); len(params) > 0 {
+		return BindError{Missing: params}
+	}
+	return nil
+}
+
+// Bind returns a new operation by replacing the params in the operation
+// and its sub-operations with the values. The original operation
+// is not modified, so it can be bound concurrently.
+//
+// The typed slice or map, such as []Param, is rebuilt as []any or map[K]any
+// if the value cannot be assigned to its element.
+//
+// If any param has no value, any value has no param, or any param
+// cannot be replaced, such as the struct field of type Param with
+// a non-Param value, return a BindError.
+func Bind[T Oper](o T, values map[string]any) (T, error) {
+	var err BindError
+	params := Params(o)
+	for _, name := range params {
+		if _, ok := values[name]; !ok {
+			err.Missing = append(err.Missing, name)
+		}
+	}
+
+	for name := range values {
+		if i := sort.SearchStrings(params, name); i == len(params) || params[i] != name {
+			err.Unused = append(err.Unused, name)
+		}
+	}
+
+	if len(err.Missing) > 0 || len(err.Unused) > 0 {
+		sort.Strings(err.Unused)
+		var zero T
+		return zero, err
+	}
+
+	if len(params) == 0 {
+		return o, nil
+	}
+
+	result := mapValues(o, func(v any) (any, bool) {
+		if p, ok := v.(Param); ok {
+			return values[string(p)], true
+		}
+		return nil, false
+	})
+
+	if params := Params(result); len(params) > 0 {
+		var zero T
+		return zero, BindError{Unbound: params}
+	}
+	return result, nil
+}
+
+// Prepare prepares the operation for the builders in one pass,
+// which returns the operation with the relative times resolved against
+// Clock, the sorted names of the unbound params, and whether it contains
+// any Expr. It is equal to, but faster than, calling ResolveTimes, Params
+// and HasExpr in turn.
+func Prepare[T Oper](o T) (result T, params []string, hasExpr bool) {
+	now := Clock()
+	names := make(map[string]struct{})
+	result = mapValues(o, func(v any) (any, bool) {
+		switch v := v.(type) {
+		case Param:
+			names[string(v)] = struct{}{}
+		case Expr:
+			hasExpr = true
+		case RelTime:
+			return v.At(now), true
+		}
+		return nil, false
+	})
+	return result, sortedNames(names), hasExpr
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBind(t *testing.T) {
+	tmpl := And(
+		Equal("user_id", Param("uid")),
+		Key("status").In(Param("statuses")),
+		In("type", []any{1, Param("type")}),
+		Between("age", Param("min"), 60),
+		Not(Equal("name", Param("uid"))),
+	)
+
+	if params := Params(tmpl); !reflect.DeepEqual(params, []string{"min", "statuses", "type", "uid"}) {
+		t.Errorf("unexpected params %v", params)
+	}
+	if !HasParams(tmpl) {
+		t.Errorf("expect having the params, but got none")
+	}
+
+	cond, err := Bind(tmpl, map[string]any{
+		"uid":      123,
+		"statuses": []string{"a", "b"},
+		"type":     2,
+		"min":      18,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := And(
+		Equal("user_id", 123),
+		Key("status").In([]string{"a", "b"}),
+		In("type", []any{1, 2}),
+		Between("age", 18, 60),
+		Not(Equal("name", 123)),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+	if HasParams(cond) {
+		t.Errorf("unexpected params %v", Params(cond))
+	}
+
+	if params := Params(tmpl); len(params) != 4 {
+		t.Errorf("the template has been modified: %v", tmpl)
+	}
+	if v := tmpl.Op().Val.([]Condition)[2].Op().Val.([]any)[1]; v != Param("type") {
+		t.Errorf("the template has been modified: %v", v)
+	}
+
+	_, err = Bind(tmpl, map[string]any{"uid": 1, "type": 2, "other": 3})
+	var berr BindError
+	if !errors.As(err, &berr) {
+		t.Fatalf("expect a BindError, but got %v", err)
+	}
+	if expect := []string{"min", "statuses"}; !reflect.DeepEqual(berr.Missing, expect) {
+		t.Errorf("expect the missing params %v, but got %v", expect, berr.Missing)
+	}
+	if expect := []string{"other"}; !reflect.DeepEqual(berr.Unused, expect) {
+		t.Errorf("expect the unused params %v, but got %v", expect, berr.Unused)
+	}
+	if s, expect := err.Error(), "missing params: min, statuses; unused params: other"; s != expect {
+		t.Errorf("expect the error '%s', but got '%s'", expect, s)
+	}
+}
+
+func TestBindUpdater(t *testing.T) {
+	up, err := Bind(Batch(Set("name", Param("name")), Inc("count")), map[string]any{"name": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := Batch(Set("name", "abc"), Inc("count")); !reflect.DeepEqual(up, expect) {
+		t.Errorf("expect %v, but got %v", expect, up)
+	}
+
+	if up, err := Bind(Set("name", "abc"), nil); err != nil {
+		t.Error(err)
+	} else if expect := Set("name", "abc"); !reflect.DeepEqual(up, expect) {
+		t.Errorf("expect %v, but got %v", expect, up)
+	}
+
+	if err := CheckParams(Set("name", Param("name"))); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+func TestBindTypedSlice(t *testing.T) {
+	c, err := Bind(In("status", []Param{"a", "b"}), map[string]any{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := Key("status").In([]any{1, 2}); !reflect.DeepEqual(c, expect) {
+		t.Errorf("expect %v, but got %v", expect, c)
+	}
+	if params := Params(c); len(params) > 0 {
+		t.Errorf("unexpected unbound params %v", params)
+	}
+
+	c, err = Bind(Key("status").In(map[string]Param{"x": "a"}), map[string]any{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := Key("status").In(map[string]any{"x": 1}); !reflect.DeepEqual(c, expect) {
+		t.Errorf("expect %v, but got %v", expect, c)
+	}
+
+	type value struct{ P Param }
+	_, err = Bind(Equal("v", value{P: "a"}), map[string]any{"a": 1})
+	if berr, ok := err.(BindError); !ok {
+		t.Errorf("expect a BindError, but got %v", err)
+	} else if expect := []string{"a"}; !reflect.DeepEqual(berr.Unbound, expect) {
+		t.Errorf("expect the unbound params %v, but got %v", expect, berr.Unbound)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer func(clock func() time.Time) { Clock = clock }(Clock)
+	Clock = func() time.Time { return now }
+
+	c, params, hasExpr := Prepare(And(
+		In("name", []string{"a", "b"}),
+		Greater("created_at", Ago(time.Hour)),
+		Equal("user_id", Param("uid")),
+	))
+
+	expect := And(
+		In("name", []string{"a", "b"}),
+		Greater("created_at", now.Add(-time.Hour)),
+		Equal("user_id", Param("uid")),
+	)
+	if !reflect.DeepEqual(c, expect) {
+		t.Errorf("expect %v, but got %v", expect, c)
+	}
+	if expect := []string{"uid"}; !reflect.DeepEqual(params, expect) {
+		t.Errorf("expect the params %v, but got %v", expect, params)
+	}
+	if hasExpr {
+		t.Errorf("expect no expression, but got one")
+	}
+
+	if _, _, hasExpr := Prepare(Col("a").Greater(Col("b"))); !hasExpr {
+		t.Errorf("expect an expression, but got none")
+	}
+}
@@ -0,0 +1,613 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError represents a syntax error of the condition expression.
+type SyntaxError struct {
+	Pos int // The byte offset in the expression, starting with 1.
+	Msg string
+}
+
+// Error implements the interface error.
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// ParseCondition parses the condition expression, such as
+//
+//	age >= 18 and (status in ("active", "trial") or name like "a%") and deleted_at is null
+//
+// The grammar is as follow, and the keywords are case-insensitive:
+//
+//	expr      = or
+//	or        = and { "or" and }
+//	and       = not { "and" not }
+//	not       = "not" not | "(" expr ")" | predicate
+//	predicate = key ( "=" | "==" | "!=" | "<>" | "<" | "<=" | ">" | ">=" ) ( literal | key )
+//	          | key [ "not" ] "in" "(" [ literal { "," literal } ] ")"
+//	          | key [ "not" ] "like" string
+//	          | key [ "not" ] "between" literal "and" literal
+//	          | key "is" [ "not" ] "null"
+//	key       = identifier | "`" any characters except "`" "`"
+//	literal   = string | number | "true" | "false" | "null"
+//
+// The identifier consists of the letters, digits, "_" and ".", such as "user.id".
+// The string is quoted by the double or single quotes, and supports the escape
+// characters "\n", "\r", "\t", "\\", "\"" and "\'". The number without "."
+// or the exponent is parsed as int64, or float64.
+//
+// If the right of the comparison is a key, it is a key comparison, such as
+// CondOpEqualKey. "key = null" and "key != null" are equal to "key is null"
+// and "key is not null".
+//
+// If failing to parse the expression, return a SyntaxError.
+func ParseCondition(expr string) (Condition, error) {
+	p := &condParser{lexer: condLexer{src: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return cond, nil
+}
+
+/// ---------------------------------------------------------------------- ///
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // The raw text for the operator and number, or the unquoted text.
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenQuotedIdent:
+		return "`" + t.text + "`"
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// keyword reports whether the token is the keyword, which is case-insensitive.
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+var condKeywords = []string{"and", "or", "not", "in", "is", "null", "like", "between", "true", "false"}
+
+func isCondKeyword(s string) bool {
+	for _, kw := range condKeywords {
+		if strings.EqualFold(s, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+type condLexer struct {
+	src string
+	pos int
+}
+
+func (l *condLexer) errorf(pos int, format string, args ...any) error {
+	return SyntaxError{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *condLexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		r, n := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += n
+	}
+
+	start := l.pos
+	if start >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	switch c := l.src[start]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, text: "(", pos: start}, nil
+
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, text: ")", pos: start}, nil
+
+	case c == ',':
+		l.pos++
+		return token{kind: tokenComma, text: ",", pos: start}, nil
+
+	case c == '=' || c == '!' || c == '<' || c == '>':
+		for _, op := range []string{"==", "!=", "<>", "<=", ">=", "=", "<", ">"} {
+			if strings.HasPrefix(l.src[start:], op) {
+				l.pos += len(op)
+				return token{kind: tokenOperator, text: op, pos: start}, nil
+			}
+		}
+		return token{}, l.errorf(start, "unexpected character '%c'", c)
+
+	case c == '"' || c == '\'':
+		return l.readString(c)
+
+	case c == '`':
+		end := strings.IndexByte(l.src[start+1:], '`')
+		if end < 0 {
+			return token{}, l.errorf(start, "unterminated quoted key")
+		}
+		l.pos = start + end + 2
+		return token{kind: tokenQuotedIdent, text: l.src[start+1 : start+1+end], pos: start}, nil
+
+	case c == '-' || c == '+' || c == '.' || ('0' <= c && c <= '9'):
+		return l.readNumber()
+
+	default:
+		for l.pos < len(l.src) {
+			r, n := utf8.DecodeRuneInString(l.src[l.pos:])
+			if !isIdentRune(r) {
+				break
+			}
+			l.pos += n
+		}
+
+		if l.pos == start {
+			r, _ := utf8.DecodeRuneInString(l.src[start:])
+			return token{}, l.errorf(start, "unexpected character '%c'", r)
+		}
+		return token{kind: tokenIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+}
+
+func (l *condLexer) readNumber() (token, error) {
+	start := l.pos
+	if c := l.src[l.pos]; c == '-' || c == '+' {
+		l.pos++
+	}
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if ('0' <= c && c <= '9') || c == '.' || c == 'e' || c == 'E' ||
+			((c == '-' || c == '+') && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E')) {
+			l.pos++
+		} else {
+			break
+		}
+	}
+
+	text := l.src[start:l.pos]
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return token{}, l.errorf(start, "invalid number '%s'", text)
+	}
+	return token{kind: tokenNumber, text: text, pos: start}, nil
+}
+
+func (l *condLexer) readString(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case quote:
+			l.pos++
+			return token{kind: tokenString, text: b.String(), pos: start}, nil
+
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(start, "unterminated string")
+			}
+
+			l.pos++
+			switch c = l.src[l.pos]; c {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(c)
+			default:
+				return token{}, l.errorf(l.pos-1, "invalid escape character '\\%c'", c)
+			}
+			l.pos++
+
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+/// ---------------------------------------------------------------------- ///
+
+type condParser struct {
+	lexer condLexer
+	tok   token
+}
+
+func (p *condParser) next() (err error) {
+	p.tok, err = p.lexer.next()
+	return
+}
+
+func (p *condParser) errorf(format string, args ...any) error {
+	return p.lexer.errorf(p.tok.pos, format, args...)
+}
+
+func (p *condParser) expect(kind tokenKind, desc string) error {
+	if p.tok.kind != kind {
+		return p.errorf("expect %s, but got %s", desc, p.tok)
+	}
+	return p.next()
+}
+
+func (p *condParser) expectKeyword(kw string) error {
+	if !p.tok.keyword(kw) {
+		return p.errorf("expect '%s', but got %s", kw, p.tok)
+	}
+	return p.next()
+}
+
+func (p *condParser) parseOr() (Condition, error) {
+	return p.parseBinary("or", CondOpOr, p.parseAnd)
+}
+
+func (p *condParser) parseAnd() (Condition, error) {
+	return p.parseBinary("and", CondOpAnd, p.parseNot)
+}
+
+func (p *condParser) parseBinary(kw, op string, parse func() (Condition, error)) (Condition, error) {
+	cond, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	conds := []Condition{cond}
+	for p.tok.keyword(kw) {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+
+		if cond, err = parse(); err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return New(op, "", conds).Condition(), nil
+}
+
+func (p *condParser) parseNot() (Condition, error) {
+	switch {
+	case p.tok.keyword("not"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		cond, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(cond), nil
+
+	case p.tok.kind == tokenLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(tokenRParen, "')'")
+
+	default:
+		return p.parsePredicate()
+	}
+}
+
+func (p *condParser) parseKey() (string, bool) {
+	switch p.tok.kind {
+	case tokenQuotedIdent:
+		return p.tok.text, true
+	case tokenIdent:
+		return p.tok.text, !isCondKeyword(p.tok.text)
+	default:
+		return "", false
+	}
+}
+
+func (p *condParser) parsePredicate() (Condition, error) {
+	key, ok := p.parseKey()
+	if !ok {
+		return nil, p.errorf("expect a key, but got %s", p.tok)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.tok.kind == tokenOperator:
+		return p.parseComparison(key)
+
+	case p.tok.keyword("is"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		not := p.tok.keyword("not")
+		if not {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.expectKeyword("null"); err != nil {
+			return nil, err
+		} else if not {
+			return IsNotNull(key), nil
+		}
+		return IsNull(key), nil
+	}
+
+	not := p.tok.keyword("not")
+	if not {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	var cond Condition
+	var err error
+	switch {
+	case p.tok.keyword("in"):
+		cond, err = p.parseIn(key, not)
+
+	case p.tok.keyword("like"):
+		cond, err = p.parseLike(key, not)
+
+	case p.tok.keyword("between"):
+		cond, err = p.parseBetween(key, not)
+
+	case not:
+		err = p.errorf("expect 'in', 'like' or 'between', but got %s", p.tok)
+
+	default:
+		err = p.errorf("expect an operator, but got %s", p.tok)
+	}
+	return cond, err
+}
+
+func (p *condParser) parseComparison(key string) (Condition, error) {
+	op := p.tok.text
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if other, ok := p.parseKey(); ok {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "=", "==":
+			return EqualKey(key, other), nil
+		case "!=", "<>":
+			return NotEqualKey(key, other), nil
+		case "<":
+			return LessKey(key, other), nil
+		case "<=":
+			return LessEqualKey(key, other), nil
+		case ">":
+			return GreaterKey(key, other), nil
+		default:
+			return GreaterEqualKey(key, other), nil
+		}
+	}
+
+	pos := p.tok.pos
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "=", "==":
+		if value == nil {
+			return IsNull(key), nil
+		}
+		return Equal(key, value), nil
+
+	case "!=", "<>":
+		if value == nil {
+			return IsNotNull(key), nil
+		}
+		return NotEqual(key, value), nil
+	}
+
+	if value == nil {
+		return nil, p.lexer.errorf(pos, "null cannot be compared by '%s'", op)
+	}
+
+	switch op {
+	case "<":
+		return Less(key, value), nil
+	case "<=":
+		return LessEqual(key, value), nil
+	case ">":
+		return Greater(key, value), nil
+	default:
+		return GreaterEqual(key, value), nil
+	}
+}
+
+func (p *condParser) parseIn(key string, not bool) (Condition, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	values := []any{}
+	for p.tok.kind != tokenRParen {
+		if len(values) > 0 {
+			if err := p.expect(tokenComma, "',' or ')'"); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if not {
+		return NotIn(key, values), nil
+	}
+	return In(key, values), nil
+}
+
+func (p *condParser) parseLike(key string, not bool) (Condition, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenString {
+		return nil, p.errorf("expect a string, but got %s", p.tok)
+	}
+
+	pattern := p.tok.text
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if not {
+		return NotLike(key, pattern), nil
+	}
+	return Like(key, pattern), nil
+}
+
+func (p *condParser) parseBetween(key string, not bool) (Condition, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	lower, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.expectKeyword("and"); err != nil {
+		return nil, err
+	}
+
+	upper, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	if not {
+		return NotBetween(key, lower, upper), nil
+	}
+	return Between(key, lower, upper), nil
+}
+
+func (p *condParser) parseLiteral() (value any, err error) {
+	switch tok := p.tok; {
+	case tok.kind == tokenString:
+		value = tok.text
+
+	case tok.kind == tokenNumber:
+		if value, err = parseNumber(tok.text); err != nil {
+			return nil, p.errorf("invalid number '%s'", tok.text)
+		}
+
+	case tok.keyword("true"):
+		value = true
+
+	case tok.keyword("false"):
+		value = false
+
+	case tok.keyword("null"):
+		value = nil
+
+	default:
+		return nil, p.errorf("expect a literal, but got %s", tok)
+	}
+
+	return value, p.next()
+}
+
+func parseNumber(s string) (any, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCondition(t *testing.T) {
+	cond, err := ParseCondition(`age >= 18 and (status in ("active",'trial') or name like "a%")` +
+		` AND deleted_at IS NULL and not (score between -1.5 and 1e3 or a.b = c) and x != null`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := And(
+		GtEq("age", int64(18)),
+		Or(In("status", []any{"active", "trial"}), Like("name", "a%")),
+		IsNull("deleted_at"),
+		Not(Or(Between("score", -1.5, 1e3), EqualKey("a.b", "c"))),
+		IsNotNull("x"),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Fatalf("expect %v, but got %v", expect, cond)
+	}
+
+	s, err := Format(cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const formatted = `age >= 18 and (status in ("active", "trial") or name like "a%") and deleted_at is null` +
+		` and not (score between -1.5 and 1000.0 or a.b = c) and x is not null`
+	if s != formatted {
+		t.Errorf("expect '%s', but got '%s'", formatted, s)
+	}
+
+	if cond, err = ParseCondition(s); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	conds := []Condition{
+		And(Eq("a", int64(1)), And(Eq("b", "x\"y\\z\n"), NotLike("c", "%_")), Or(Eq("d", true), NotIn("e", []any{}))),
+		Or(And(Le("a", 1.5), Gt("b", false)), Or(LessEqualKey("c", "d"), NotBetween("and", int64(1), int64(2)))),
+		Not(Not(GreaterKey("x-y", "z"))),
+	}
+
+	for _, cond := range conds {
+		s, err := Format(cond)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := ParseCondition(s)
+		if err != nil {
+			t.Errorf("fail to parse '%s': %v", s, err)
+		} else if !reflect.DeepEqual(result, cond) {
+			t.Errorf("'%s': expect %v, but got %v", s, cond, result)
+		}
+	}
+}
+
+func TestFormatNull(t *testing.T) {
+	for i, c := range []struct {
+		cond   Condition
+		expr   string
+		expect Condition
+	}{
+		{Eq("a", nil), "a is null", IsNull("a")},
+		{NotEq("a", nil), "a is not null", IsNotNull("a")},
+		{And(Eq("a", nil), IsNull("b")), "a is null and b is null", And(IsNull("a"), IsNull("b"))},
+	} {
+		s, err := Format(c.cond)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		} else if s != c.expr {
+			t.Errorf("%d: expect '%s', but got '%s'", i, c.expr, s)
+		}
+
+		if result, err := ParseCondition(s); err != nil {
+			t.Errorf("%d: fail to parse '%s': %v", i, s, err)
+		} else if !reflect.DeepEqual(result, c.expect) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, result)
+		}
+	}
+}
+
+func TestParseConditionError(t *testing.T) {
+	for expr, pos := range map[string]int{
+		`age >`:            6,
+		`age >= 18 and`:    14,
+		`(a = 1`:           7,
+		`a in (1, 2`:       11,
+		`a = "x`:           5,
+		`a like 1`:         8,
+		`a < null`:         5,
+		`a = 1 b = 2`:      7,
+		`a between 1 or 2`: 13,
+	} {
+		_, err := ParseCondition(expr)
+		var serr SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("%s: expect a SyntaxError, but got %v", expr, err)
+		} else if serr.Pos != pos {
+			t.Errorf("%s: expect position %d, but got %d: %s", expr, pos, serr.Pos, serr.Msg)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	for _, cond := range []Condition{
+		Eq("`a`", 1),
+		Eq("a", []int{1}),
+		New(CondOpIn, "a", 1).Condition(),
+		Or(),
+		Col("a").Greater(1),
+		Eq("a", Col("b")),
+		Gt("a", Ago(time.Hour)),
+		Le("a", nil),
+	} {
+		if s, err := Format(cond); err == nil {
+			t.Errorf("expect an error, but got '%s'", s)
+		}
+	}
+}
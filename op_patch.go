@@ -0,0 +1,292 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pre-define the operations of RFC 6902 JSON Patch.
+const (
+	JSONPatchOpAdd     = "add"
+	JSONPatchOpRemove  = "remove"
+	JSONPatchOpReplace = "replace"
+	JSONPatchOpMove    = "move"
+	JSONPatchOpCopy    = "copy"
+	JSONPatchOpTest    = "test"
+)
+
+// JSONPatchOp represents an operation of RFC 6902 JSON Patch.
+type JSONPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value"`
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// ParseMergePatch parses the RFC 7396 JSON Merge Patch document
+// and converts it to the updaters by MergePatch.
+func ParseMergePatch(data []byte) ([]Updater, error) {
+	var patch map[string]any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("op.ParseMergePatch: %w", err)
+	}
+	return MergePatch(patch)
+}
+
+// MergePatch converts the RFC 7396 JSON Merge Patch to the updaters.
+//
+// The nested object is converted to the updaters with the scoped keys,
+// such as "a.b", and null is converted to Unset(key).
+// The updaters are sorted by the key.
+//
+// Since the member name containing Sep cannot be told apart from the nested
+// object, such as "a.b" and {"a":{"b":...}}, it returns an error.
+func MergePatch(patch map[string]any) ([]Updater, error) {
+	return mergePatch(nil, "", patch)
+}
+
+func mergePatch(ups []Updater, scope string, patch map[string]any) (_ []Updater, err error) {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		if strings.Contains(key, Sep) {
+			return nil, fmt.Errorf("op.MergePatch: unsupported member name '%s' containing '%s'", key, Sep)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		op := Key(key).Scope(scope)
+		switch value := patch[key].(type) {
+		case map[string]any:
+			if ups, err = mergePatch(ups, op.Key, value); err != nil {
+				return nil, err
+			}
+		case nil:
+			ups = append(ups, op.Unset())
+		default:
+			ups = append(ups, op.Set(value))
+		}
+	}
+	return ups, nil
+}
+
+// ToMergePatch converts the updaters to the RFC 7396 JSON Merge Patch,
+// which is the reverse of MergePatch.
+//
+// Only UpdateOpSet, UpdateOpUnset and UpdateOpBatch are supported.
+// For other operations, or the set operation whose value is a KV, return an error.
+// If the keys conflict, such as "a" and "a.b", also return an error,
+// since the scalar value cannot have the nested member.
+func ToMergePatch(ups ...Updater) (map[string]any, error) {
+	patch := make(map[string]any, len(ups))
+	for _, up := range flattenUpdaters(ups) {
+		op := up.Op()
+		switch op.Op {
+		case UpdateOpSet:
+		case UpdateOpUnset:
+			op.Val = nil
+		default:
+			return nil, fmt.Errorf("op.ToMergePatch: unsupported update operation '%s'", op.Op)
+		}
+
+		if _, ok := op.Val.(KV); ok {
+			return nil, fmt.Errorf("op.ToMergePatch: unsupported key-value of key '%s'", op.Key)
+		}
+
+		obj := patch
+		keys := strings.Split(op.Key, Sep)
+		for _, key := range keys[:len(keys)-1] {
+			value, exists := obj[key]
+			sub, ok := value.(map[string]any)
+			switch {
+			case !exists:
+				sub = make(map[string]any)
+				obj[key] = sub
+			case !ok:
+				return nil, fmt.Errorf("op.ToMergePatch: conflicting key '%s'", op.Key)
+			}
+			obj = sub
+		}
+
+		key := keys[len(keys)-1]
+		if _, ok := obj[key].(map[string]any); ok {
+			return nil, fmt.Errorf("op.ToMergePatch: conflicting key '%s'", op.Key)
+		}
+		obj[key] = op.Val
+	}
+	return patch, nil
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// ParseJSONPatch parses the RFC 6902 JSON Patch document
+// and converts it to the updaters and conditions by JSONPatch.
+func ParseJSONPatch(data []byte) ([]Updater, []Condition, error) {
+	var patch []JSONPatchOp
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, nil, fmt.Errorf("op.ParseJSONPatch: %w", err)
+	}
+	return JSONPatch(patch...)
+}
+
+// JSONPatch converts the RFC 6902 JSON Patch operations
+// to the updaters and conditions.
+//
+// The JSON pointer path is converted to the scoped key, such as "/a/b" to "a.b".
+// The operations are converted as follow:
+//
+//	add, replace: Set(key, value)
+//	remove:       Unset(key)
+//	copy:         Key(key).SetKey(from)
+//	move:         Key(key).SetKey(from), Unset(from)
+//	test:         Equal(key, value)
+//
+// The array index in the path is not supported, which returns an error.
+// Neither is the token containing Sep, such as "/a.b", which cannot be told
+// apart from the nested path "/a/b" after being converted.
+func JSONPatch(patch ...JSONPatchOp) (ups []Updater, conds []Condition, err error) {
+	for i, p := range patch {
+		var key, from string
+		if key, err = jsonPointerToKey(p.Path); err != nil {
+			return nil, nil, fmt.Errorf("op.JSONPatch: %dth operation: %w", i, err)
+		}
+
+		switch p.Op {
+		case JSONPatchOpAdd, JSONPatchOpReplace:
+			ups = append(ups, Set(key, p.Value))
+
+		case JSONPatchOpRemove:
+			ups = append(ups, Unset(key))
+
+		case JSONPatchOpTest:
+			conds = append(conds, Equal(key, p.Value))
+
+		case JSONPatchOpCopy, JSONPatchOpMove:
+			if from, err = jsonPointerToKey(p.From); err != nil {
+				return nil, nil, fmt.Errorf("op.JSONPatch: %dth operation: %w", i, err)
+			}
+
+			ups = append(ups, Key(key).SetKey(from))
+			if p.Op == JSONPatchOpMove {
+				ups = append(ups, Unset(from))
+			}
+
+		default:
+			return nil, nil, fmt.Errorf("op.JSONPatch: %dth operation: unknown op '%s'", i, p.Op)
+		}
+	}
+	return
+}
+
+// ToJSONPatch converts the updaters and conditions to the RFC 6902 JSON Patch,
+// which is the reverse of JSONPatch.
+//
+// The conditions are converted to the test operations in front of others,
+// which only supports CondOpEqual and CondOpAnd. And the updaters only
+// support UpdateOpSet, UpdateOpUnset and UpdateOpBatch.
+func ToJSONPatch(ups []Updater, conds []Condition) (patch []JSONPatchOp, err error) {
+	patch = make([]JSONPatchOp, 0, len(ups)+len(conds))
+	if patch, err = appendJSONPatchTests(patch, conds); err != nil {
+		return nil, err
+	}
+
+	for _, up := range flattenUpdaters(ups) {
+		op := up.Op()
+		switch op.Op {
+		case UpdateOpSet:
+		case UpdateOpUnset:
+			op.Val = nil
+		default:
+			return nil, fmt.Errorf("op.ToJSONPatch: unsupported update operation '%s'", op.Op)
+		}
+
+		path := keyToJSONPointer(op.Key)
+		switch v := op.Val.(type) {
+		case nil:
+			patch = append(patch, JSONPatchOp{Op: JSONPatchOpRemove, Path: path})
+		case KV:
+			from := keyToJSONPointer(v.Key)
+			patch = append(patch, JSONPatchOp{Op: JSONPatchOpCopy, Path: path, From: from})
+		default:
+			patch = append(patch, JSONPatchOp{Op: JSONPatchOpReplace, Path: path, Value: v})
+		}
+	}
+
+	return
+}
+
+func appendJSONPatchTests(patch []JSONPatchOp, conds []Condition) ([]JSONPatchOp, error) {
+	var err error
+	for _, cond := range conds {
+		switch op := cond.Op(); op.Op {
+		case CondOpEqual:
+			path := keyToJSONPointer(op.Key)
+			patch = append(patch, JSONPatchOp{Op: JSONPatchOpTest, Path: path, Value: op.Val})
+
+		case CondOpAnd:
+			if patch, err = appendJSONPatchTests(patch, op.Val.([]Condition)); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("op.ToJSONPatch: unsupported condition operation '%s'", op.Op)
+		}
+	}
+	return patch, nil
+}
+
+var (
+	jsonPointerDecoder = strings.NewReplacer("~1", "/", "~0", "~")
+	jsonPointerEncoder = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+func jsonPointerToKey(path string) (string, error) {
+	if path == "" || path[0] != '/' {
+		return "", fmt.Errorf("invalid json pointer '%s'", path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		if token == "" || token == "-" || isDigits(token) || strings.Contains(token, Sep) {
+			return "", fmt.Errorf("unsupported json pointer '%s'", path)
+		}
+		tokens[i] = jsonPointerDecoder.Replace(token)
+	}
+	return strings.Join(tokens, Sep), nil
+}
+
+func keyToJSONPointer(key string) string {
+	tokens := strings.Split(key, Sep)
+	for i, token := range tokens {
+		tokens[i] = jsonPointerEncoder.Replace(token)
+	}
+	return "/" + strings.Join(tokens, "/")
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	ups, err := ParseMergePatch([]byte(`{"name":"Aaron","addr":{"city":"Beijing","zip":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []Updater{Set("addr.city", "Beijing"), Unset("addr.zip"), Set("name", "Aaron")}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Fatalf("expect %v, but got %v", expects, ups)
+	}
+
+	patch, err := ToMergePatch(ups...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(patch)
+	if expect := `{"addr":{"city":"Beijing","zip":null},"name":"Aaron"}`; string(data) != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, data)
+	}
+
+	if _, err := ToMergePatch(Inc("count")); err == nil {
+		t.Error("expect an error, but got nil")
+	}
+
+	if ups, err := ParseMergePatch([]byte(`{"a.b":1}`)); err == nil {
+		t.Errorf("expect an error for the member name containing Sep, but got %v", ups)
+	}
+
+	for i, ups := range [][]Updater{
+		{Set("a", 1), Set("a.b", 2)},
+		{Set("a.b", 2), Set("a", 1)},
+	} {
+		if patch, err := ToMergePatch(ups...); err == nil {
+			t.Errorf("%d: expect an error for the conflicting keys, but got %v", i, patch)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	ups, conds, err := ParseJSONPatch([]byte(`[
+		{"op":"test","path":"/version","value":1},
+		{"op":"replace","path":"/a~1b","value":"v"},
+		{"op":"remove","path":"/c/d"},
+		{"op":"move","path":"/e","from":"/f"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectConds := []Condition{Equal("version", float64(1))}
+	if !reflect.DeepEqual(conds, expectConds) {
+		t.Errorf("expect conditions %v, but got %v", expectConds, conds)
+	}
+
+	expectUps := []Updater{Set("a/b", "v"), Unset("c.d"), Key("e").SetKey("f"), Unset("f")}
+	if !reflect.DeepEqual(ups, expectUps) {
+		t.Errorf("expect updaters %v, but got %v", expectUps, ups)
+	}
+
+	patch, err := ToJSONPatch(ups, conds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []JSONPatchOp{
+		{Op: JSONPatchOpTest, Path: "/version", Value: float64(1)},
+		{Op: JSONPatchOpReplace, Path: "/a~1b", Value: "v"},
+		{Op: JSONPatchOpRemove, Path: "/c/d"},
+		{Op: JSONPatchOpCopy, Path: "/e", From: "/f"},
+		{Op: JSONPatchOpRemove, Path: "/f"},
+	}
+	if !reflect.DeepEqual(patch, expects) {
+		t.Errorf("expect %v, but got %v", expects, patch)
+	}
+
+	if _, _, err := JSONPatch(JSONPatchOp{Op: JSONPatchOpAdd, Path: "/tags/0"}); err == nil {
+		t.Error("expect an error for the array index, but got nil")
+	}
+
+	if ups, _, err := JSONPatch(JSONPatchOp{Op: JSONPatchOpAdd, Path: "/a.b", Value: 1}); err == nil {
+		t.Errorf("expect an error for the token containing Sep, but got %v", ups)
+	}
+}
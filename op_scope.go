@@ -0,0 +1,116 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAmbiguousScope represents that the condition refers the keys
+// both with and without the scope, so the key without the scope
+// is ambiguous, such as "id" in the SQL join of the tables a and b.
+var ErrAmbiguousScope = errors.New("ambiguous scope: the keys with and without the scope are mixed")
+
+// checkScopes returns ErrAmbiguousScope if the scopes contain
+// both the empty scope and the non-empty scopes.
+func checkScopes(scopes []string) error {
+	if len(scopes) < 2 {
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if scope == "" {
+			return fmt.Errorf("%w: %q", ErrAmbiguousScope, scopes)
+		}
+	}
+	return nil
+}
+
+// Scopes returns the scopes of the keys referred by the condition,
+// which are deduplicated and in order of the first occurrence.
+//
+// The scope of the key is the part before the last Sep, such as "t"
+// for "t.id", or empty for "id". Besides the key of the condition,
+// the keys in the value are also referred, such as the other key
+// of EqualKey, the longitude key of GeoRadius and the columns of Expr.
+func Scopes(c Condition) []string {
+	var scopes []string
+	condKeys(c, func(key string) {
+		var scope string
+		if index := strings.LastIndex(key, Sep); index > -1 {
+			scope = key[:index]
+		}
+
+		for _, s := range scopes {
+			if s == scope {
+				return
+			}
+		}
+		scopes = append(scopes, scope)
+	})
+	return scopes
+}
+
+// condKeys calls visit with each key referred by the leaf conditions.
+func condKeys(c Condition, visit func(key string)) {
+	if c == nil {
+		return
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case CondOpAnd, CondOpOr:
+		conds, _ := o.Val.([]Condition)
+		for _, cond := range conds {
+			condKeys(cond, visit)
+		}
+		return
+
+	case CondOpNot:
+		cond, _ := o.Val.(Condition)
+		condKeys(cond, visit)
+		return
+
+	}
+
+	if o.Key != "" {
+		visit(o.Key)
+	}
+
+	switch v := o.Val.(type) {
+	case string:
+		switch o.Op {
+		case CondOpEqualKey, CondOpNotEqualKey, CondOpLessKey,
+			CondOpLessEqualKey, CondOpGreaterKey, CondOpGreaterEqualKey:
+			visit(v)
+		}
+	case Expr:
+		for _, key := range v.Keys() {
+			visit(key)
+		}
+	case GeoRadius:
+		visit(v.LngKey)
+	case GeoBox:
+		visit(v.LngKey)
+	case GeoPolygon:
+		visit(v.LngKey)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsatisfiable represents that the condition is always false,
+// so the query has an empty result.
+var ErrUnsatisfiable = errors.New("unsatisfiable condition")
+
+// Unsatisfiable reports whether the condition is always false,
+// such as And(Greater("age", 30), Less("age", 20)), In("id", []int{})
+// and And(IsNull("x"), Equal("x", 5)).
+//
+// It uses the same reasoning as Implies, and returns false
+// conservatively if it cannot be decided.
+func Unsatisfiable(c Condition) (bool, error) {
+	var cmp comparator
+	node, err := cmp.newCondNode(c, false)
+	if err != nil {
+		return false, fmt.Errorf("op.Unsatisfiable: %w", err)
+	}
+
+	ok := cmp.unsatisfiable(node)
+	return ok && !cmp.failed, nil
+}
+
+// Tautology reports whether the condition is always true, such as
+// Or(LessEqual("age", 18), Greater("age", 18), IsNull("age")).
+// nil is always true.
+//
+// Null does not satisfy the comparisons as the three-valued logic of SQL,
+// so Or(LessEqual("age", 18), Greater("age", 18)) is not always true.
+//
+// It uses the same reasoning as Implies, and returns false
+// conservatively if it cannot be decided.
+func Tautology(c Condition) (bool, error) {
+	var cmp comparator
+	node, err := cmp.newCondNode(c, false)
+	if err != nil {
+		return false, fmt.Errorf("op.Tautology: %w", err)
+	}
+
+	ok := cmp.tautology(node)
+	return ok && !cmp.failed, nil
+}
+
+func (c *comparator) tautology(n condNode) bool {
+	return c.implies(condNode{op: CondOpAnd}, n)
+}
+
+// Simplify simplifies the condition by dropping the redundant predicates
+// based on the reasoning of Implies, Unsatisfiable and Tautology.
+// For example,
+//
+//	And(Greater("age", 18), Greater("age", 30), Equal("id", 1))  =>  And(Greater("age", 30), Equal("id", 1))
+//	Or(Greater("age", 18), Greater("age", 30), In("id", []int{}))  =>  Greater("age", 18)
+//	And(Greater("age", 18), Or(IsNull("x"), IsNotNull("x")))  =>  Greater("age", 18)
+//
+// In And, the always-true predicates and the ones implied by another
+// are dropped. In Or, the always-false predicates and the ones implying
+// another are dropped.
+//
+// If the condition is always true, return (nil, nil), which represents
+// no condition. If the condition is always false, return ErrUnsatisfiable,
+// so the builder can short-circuit to an empty result.
+func Simplify(c Condition) (Condition, error) {
+	var cmp comparator
+	if _, err := cmp.newCondNode(c, false); err != nil {
+		return nil, fmt.Errorf("op.Simplify: %w", err)
+	}
+
+	c, result, _ := simplify(c)
+	switch result {
+	case alwaysTrue:
+		return nil, nil
+	case alwaysFalse:
+		return nil, ErrUnsatisfiable
+	default:
+		return c, nil
+	}
+}
+
+const (
+	alwaysUnknown = iota
+	alwaysTrue
+	alwaysFalse
+)
+
+// simplify simplifies the validated condition, and returns whether
+// it is always true or false, and whether it is changed.
+func simplify(c Condition) (_ Condition, result int, changed bool) {
+	if c == nil {
+		return nil, alwaysTrue, false
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case CondOpAnd, CondOpOr:
+		conds := o.Val.([]Condition)
+		results := make([]Condition, 0, len(conds))
+		for _, cond := range conds {
+			if cond == nil {
+				changed = true
+				continue
+			}
+
+			cond, result, _changed := simplify(cond)
+			switch {
+			case result == alwaysUnknown:
+				results = append(results, cond)
+				changed = changed || _changed
+
+			case (result == alwaysTrue) == (o.Op == CondOpAnd):
+				changed = true // Drop the always-true in And or always-false in Or.
+
+			default:
+				return nil, result, true
+			}
+		}
+
+		for i := 0; i < len(results); i++ {
+			for j := range results {
+				if i == j {
+					continue
+				}
+
+				var redundant bool
+				if o.Op == CondOpAnd {
+					redundant = implies(results[j], results[i])
+				} else {
+					redundant = implies(results[i], results[j])
+				}
+
+				if redundant {
+					results = append(results[:i], results[i+1:]...)
+					changed, i = true, i-1
+					break
+				}
+			}
+		}
+
+		switch len(results) {
+		case 0:
+			if o.Op == CondOpAnd {
+				return nil, alwaysTrue, true
+			}
+			return nil, alwaysFalse, true
+
+		case 1:
+			c, changed = results[0], true
+
+		default:
+			if changed {
+				c = o.WithValue(results).Condition()
+			}
+		}
+
+	case CondOpNot:
+		// Not is not inverted by the result of the sub-condition,
+		// since NOT NULL is still NULL, such as Not(And(Gt("age", 30), Le("age", 20)))
+		// is neither always true nor always false if age is NULL.
+		// So let the reasoning on the negation normal form below decide it.
+		sub, result, _changed := simplify(o.Val.(Condition))
+		if result == alwaysUnknown && _changed {
+			c, changed = o.WithValue(sub).Condition(), true
+		}
+	}
+
+	var cmp comparator
+	node, _ := cmp.newCondNode(c, false)
+	switch {
+	case cmp.unsatisfiable(node) && !cmp.failed:
+		return nil, alwaysFalse, true
+	case cmp.tautology(node) && !cmp.failed:
+		return nil, alwaysTrue, true
+	default:
+		return c, alwaysUnknown, changed
+	}
+}
+
+// implies is the same as Implies, but the conditions have been validated.
+func implies(a, b Condition) bool {
+	ok, _ := Implies(a, b)
+	return ok
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnsatisfiableAndTautology(t *testing.T) {
+	for i, c := range []struct {
+		cond        Condition
+		unsat, taut bool
+	}{
+		{And(Gt("age", 30), Le("age", 20)), true, false},
+		{In("id", []int{}), true, false},
+		{And(IsNull("x"), Eq("x", 5)), true, false},
+		{And(Eq("x", 1), Not(In("x", []int{1, 2}))), true, false},
+		{And(Eq("x", 1), Or(Eq("y", 1), Eq("x", 2))), false, false},
+		{And(Eq("x", 1), Or(Eq("x", 3), Eq("x", 2))), true, false},
+		{And(Gt("age", 18), Eq("x", "a")), false, false},
+		{Or(LeEq("age", 18), Gt("age", 18)), false, false},
+		{Or(LeEq("age", 18), Gt("age", 18), IsNull("age")), false, true},
+		{Or(IsNull("x"), IsNotNull("x")), false, true},
+		{NotIn("id", []int{}), false, false},
+		{Or(NotIn("id", []int{}), IsNull("id")), false, true},
+		{nil, false, true},
+		{And(Gt("age", 30), Le("age", "20")), false, false},
+		{Not(And(Gt("age", 30), Le("age", 20))), false, false},
+	} {
+		if unsat, err := Unsatisfiable(c.cond); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if unsat != c.unsat {
+			t.Errorf("%d: expect unsatisfiable %v, but got %v", i, c.unsat, unsat)
+		}
+
+		if taut, err := Tautology(c.cond); err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if taut != c.taut {
+			t.Errorf("%d: expect tautology %v, but got %v", i, c.taut, taut)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	for i, c := range []struct {
+		cond   Condition
+		expect Condition
+	}{
+		{
+			And(Gt("age", 18), Gt("age", 30), Eq("id", 1)),
+			And(Gt("age", 30), Eq("id", 1)),
+		},
+		{
+			Or(Gt("age", 18), Gt("age", 30), In("id", []int{})),
+			Gt("age", 18),
+		},
+		{
+			And(Gt("age", 18), Or(IsNull("x"), IsNotNull("x"))),
+			Gt("age", 18),
+		},
+		{
+			And(Eq("a", 1), Eq("a", 1), Like("b", "x%"), Like("b", "x%")),
+			And(Eq("a", 1), Like("b", "x%")),
+		},
+		{
+			Not(And(Gt("age", 18), Gt("age", 30))),
+			Not(Gt("age", 30)),
+		},
+		{
+			Or(And(Eq("a", 1), Eq("b", 2)), Eq("a", 1)),
+			Eq("a", 1),
+		},
+		{
+			And(Gt("age", 18), Le("age", 30)),
+			And(Gt("age", 18), Le("age", 30)),
+		},
+		{Or(IsNull("x"), IsNotNull("x")), nil},
+		{And(nil, Or(IsNull("x"), IsNotNull("x"))), nil},
+		{
+			Not(And(Gt("age", 30), Le("age", 20))),
+			Not(And(Gt("age", 30), Le("age", 20))),
+		},
+		{
+			Not(In("id", []int{})),
+			Not(In("id", []int{})),
+		},
+	} {
+		result, err := Simplify(c.cond)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if !reflect.DeepEqual(result, c.expect) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, result)
+		}
+	}
+
+	for _, cond := range []Condition{
+		And(Gt("age", 30), Le("age", 20)),
+		Or(In("id", []int{}), And(IsNull("x"), Eq("x", 5))),
+		Not(Or(IsNull("x"), IsNotNull("x"))),
+		And(Eq("a", 1), Or(Eq("a", 2), Eq("a", 3))),
+	} {
+		if result, err := Simplify(cond); !errors.Is(err, ErrUnsatisfiable) {
+			t.Errorf("expect ErrUnsatisfiable, but got %v and %v", result, err)
+		}
+	}
+
+	if _, err := Simplify(And(New(CondOpIn, "a", 1).Condition())); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
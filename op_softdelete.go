@@ -0,0 +1,173 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import "fmt"
+
+// DefaultSoftDelete is the default soft-delete policy, which is the same
+// as IsDeletedCond and IsNotDeletedCond, that is, the key is KeyDeletedAt
+// and the not-deleted value is the MySQL zero datetime "0000-00-00 00:00:00".
+var DefaultSoftDelete = ZeroTimeSoftDelete(KeyDeletedAt.Key, "0000-00-00 00:00:00")
+
+// SoftDelete is the policy of the soft deletion, which represents
+// whether the record is deleted by the value of the key.
+type SoftDelete struct {
+	// Key is the key of the deletion state, such as "deleted_at".
+	Key string
+
+	// NotDeletedValue is the value of the not-deleted state.
+	// If nil, the not-deleted state is NULL.
+	NotDeletedValue any
+
+	// DeletedValue returns the value to mark the record deleted,
+	// such as the deletion time.
+	DeletedValue func() any
+}
+
+// NullSoftDelete returns a soft-delete policy that the not-deleted state
+// is NULL and the deleted state is the deletion time returned by Clock,
+// which is common for PostgreSQL.
+func NullSoftDelete(key string) SoftDelete {
+	return SoftDelete{Key: key, DeletedValue: func() any { return Clock() }}
+}
+
+// ZeroTimeSoftDelete returns a soft-delete policy that the not-deleted state
+// is the zero time, such as "0000-00-00 00:00:00" for MySQL or time.Time{},
+// and the deleted state is the deletion time returned by Clock.
+func ZeroTimeSoftDelete(key string, zero any) SoftDelete {
+	return SoftDelete{Key: key, NotDeletedValue: zero, DeletedValue: func() any { return Clock() }}
+}
+
+// BoolSoftDelete returns a soft-delete policy that the not-deleted state
+// is false and the deleted state is true, such as "is_deleted".
+func BoolSoftDelete(key string) SoftDelete {
+	return SoftDelete{Key: key, NotDeletedValue: false, DeletedValue: func() any { return true }}
+}
+
+// IntSoftDelete returns a soft-delete policy that the not-deleted state
+// is 0 and the deleted state is the unix timestamp of the deletion time
+// returned by Clock.
+func IntSoftDelete(key string) SoftDelete {
+	return SoftDelete{Key: key, NotDeletedValue: 0, DeletedValue: func() any { return Clock().Unix() }}
+}
+
+// Scope returns a new soft-delete policy with the scoped key.
+func (p SoftDelete) Scope(name string) SoftDelete {
+	p.Key = Key(p.Key).Scope(name).Key
+	return p
+}
+
+// Deleted returns a condition that the record is deleted.
+func (p SoftDelete) Deleted() Condition {
+	if p.NotDeletedValue == nil {
+		return IsNotNull(p.Key)
+	}
+	return NotEqual(p.Key, p.NotDeletedValue)
+}
+
+// NotDeleted returns a condition that the record is not deleted.
+func (p SoftDelete) NotDeleted() Condition {
+	if p.NotDeletedValue == nil {
+		return IsNull(p.Key)
+	}
+	return Equal(p.Key, p.NotDeletedValue)
+}
+
+// MarkDeleted returns an updater to mark the record deleted.
+//
+// If DeletedValue is nil, use the current time returned by Clock.
+func (p SoftDelete) MarkDeleted() Updater {
+	if p.DeletedValue == nil {
+		return Set(p.Key, Clock())
+	}
+	return Set(p.Key, p.DeletedValue())
+}
+
+// Restore returns an updater to mark the record not deleted.
+func (p SoftDelete) Restore() Updater {
+	if p.NotDeletedValue == nil {
+		return Unset(p.Key)
+	}
+	return Set(p.Key, p.NotDeletedValue)
+}
+
+// Inject returns a new condition by appending the not-deleted conditions
+// for all the scopes referred by the condition c, which are regarded as
+// the tables and returned by Scopes. For example,
+//
+//	p := NullSoftDelete("deleted_at")
+//	p.Inject(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)))
+//	=> And(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)), IsNull("a.deleted_at"), IsNull("b.deleted_at"))
+//
+// If a top-level conjunct of c, that is, c itself or a child of the top
+// And, is a condition on the deletion key of a scope, such as p.Deleted(),
+// the scope is skipped, so the deleted records can be queried explicitly.
+// The deletion key in Or or Not does not skip the scope.
+//
+// If c refers the keys both with and without the scope, return an error
+// wrapping ErrAmbiguousScope, since the key without the scope is ambiguous
+// in the SQL join. If c is nil, return the not-deleted condition
+// without the scope.
+func (p SoftDelete) Inject(c Condition) (Condition, error) {
+	if c == nil {
+		return p.NotDeleted(), nil
+	}
+
+	scopes := Scopes(c)
+	if err := checkScopes(scopes); err != nil {
+		return nil, fmt.Errorf("op.SoftDelete: %w", err)
+	}
+
+	keys := make(map[string]struct{}, 4)
+	conjuncts(c, func(o Op) {
+		if o.Key != "" {
+			keys[o.Key] = struct{}{}
+		}
+	})
+
+	conds := []Condition{c}
+	for _, scope := range scopes {
+		scoped := p.Scope(scope)
+		if _, ok := keys[scoped.Key]; !ok {
+			conds = append(conds, scoped.NotDeleted())
+		}
+	}
+
+	if len(conds) == 1 {
+		return c, nil
+	}
+	return And(conds...), nil
+}
+
+// conjuncts calls visit with each top-level conjunct of the condition,
+// which flattens the nested And.
+func conjuncts(c Condition, visit func(Op)) {
+	if c == nil {
+		return
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	if conds, ok := o.Val.([]Condition); ok && o.Op == CondOpAnd {
+		for _, cond := range conds {
+			conjuncts(cond, visit)
+		}
+	} else {
+		visit(o)
+	}
+}
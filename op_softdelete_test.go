@@ -0,0 +1,131 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSoftDelete(t *testing.T) {
+	now := time.Date(2024, 3, 15, 20, 30, 0, 0, time.UTC)
+	defer func(clock func() time.Time) { Clock = clock }(Clock)
+	Clock = func() time.Time { return now }
+
+	for i, c := range []struct {
+		policy     SoftDelete
+		deleted    Condition
+		notDeleted Condition
+		mark       Updater
+		restore    Updater
+	}{
+		{
+			NullSoftDelete("deleted_at"),
+			IsNotNull("deleted_at"), IsNull("deleted_at"),
+			Set("deleted_at", now), Unset("deleted_at"),
+		},
+		{
+			DefaultSoftDelete,
+			IsDeletedCond, IsNotDeletedCond,
+			Set("deleted_at", now), Set("deleted_at", "0000-00-00 00:00:00"),
+		},
+		{
+			BoolSoftDelete("is_deleted"),
+			NotEqual("is_deleted", false), Equal("is_deleted", false),
+			Set("is_deleted", true), Set("is_deleted", false),
+		},
+		{
+			IntSoftDelete("deleted_at"),
+			NotEqual("deleted_at", 0), Equal("deleted_at", 0),
+			Set("deleted_at", now.Unix()), Set("deleted_at", 0),
+		},
+	} {
+		if cond := c.policy.Deleted(); !reflect.DeepEqual(cond, c.deleted) {
+			t.Errorf("%d: expect the deleted condition %v, but got %v", i, c.deleted, cond)
+		}
+		if cond := c.policy.NotDeleted(); !reflect.DeepEqual(cond, c.notDeleted) {
+			t.Errorf("%d: expect the not-deleted condition %v, but got %v", i, c.notDeleted, cond)
+		}
+		if up := c.policy.MarkDeleted(); !reflect.DeepEqual(up, c.mark) {
+			t.Errorf("%d: expect the mark updater %v, but got %v", i, c.mark, up)
+		}
+		if up := c.policy.Restore(); !reflect.DeepEqual(up, c.restore) {
+			t.Errorf("%d: expect the restore updater %v, but got %v", i, c.restore, up)
+		}
+	}
+
+	if cond := DefaultSoftDelete.Scope("t").NotDeleted(); !reflect.DeepEqual(cond, IsNotDeletedCondWithTable("t")) {
+		t.Errorf("expect %v, but got %v", IsNotDeletedCondWithTable("t"), cond)
+	}
+}
+
+func TestSoftDeleteInject(t *testing.T) {
+	p := NullSoftDelete("deleted_at")
+	for i, c := range []struct {
+		cond   Condition
+		expect Condition
+	}{
+		{nil, IsNull("deleted_at")},
+		{Equal("id", 1), And(Equal("id", 1), IsNull("deleted_at"))},
+		{
+			And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)),
+			And(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)), IsNull("a.deleted_at"), IsNull("b.deleted_at")),
+		},
+		{
+			Or(Equal("a.id", 1), Not(Equal("c.id", 2))),
+			And(Or(Equal("a.id", 1), Not(Equal("c.id", 2))), IsNull("a.deleted_at"), IsNull("c.deleted_at")),
+		},
+		{
+			And(Equal("a.id", 1), p.Scope("a").Deleted(), Equal("b.id", 2)),
+			And(And(Equal("a.id", 1), p.Scope("a").Deleted(), Equal("b.id", 2)), IsNull("b.deleted_at")),
+		},
+		{p.Deleted(), p.Deleted()},
+		{
+			Or(Equal("b.status", 1), IsNotNull("b.deleted_at")),
+			And(Or(Equal("b.status", 1), IsNotNull("b.deleted_at")), IsNull("b.deleted_at")),
+		},
+		{
+			And(Equal("b.status", 1), Not(IsNull("b.deleted_at"))),
+			And(And(Equal("b.status", 1), Not(IsNull("b.deleted_at"))), IsNull("b.deleted_at")),
+		},
+	} {
+		if cond, err := p.Inject(c.cond); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(cond, c.expect) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, cond)
+		}
+	}
+
+	if _, err := p.Inject(And(EqualKey("a.id", "b.aid"), Equal("id", 1))); !errors.Is(err, ErrAmbiguousScope) {
+		t.Errorf("expect ErrAmbiguousScope, but got %v", err)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	cond := And(
+		Equal("a.id", 1),
+		EqualKey("b.id", "c.bid"),
+		Col("d.price").Mul(Col("qty")).Greater(1),
+		WithinRadius("e.lat", "f.lng", Point{}, 1),
+		Equal("a.name", "x"),
+	)
+
+	expect := []string{"a", "b", "c", "d", "", "e", "f"}
+	if scopes := Scopes(cond); !reflect.DeepEqual(scopes, expect) {
+		t.Errorf("expect the scopes %v, but got %v", expect, scopes)
+	}
+}
@@ -33,12 +33,12 @@ type Sorter interface {
 	Oper
 }
 
-type sort struct{ oper }
+type sorter struct{ oper }
 
-func (s sort) sort() {}
+func (s sorter) sort() {}
 
 // Sorter converts itself to Sorter.
-func (o Op) Sorter() Sorter { return sort{oper{o.WithKind(KindSort)}} }
+func (o Op) Sorter() Sorter { return sorter{oper{o.WithKind(KindSort)}} }
 
 /// ---------------------------------------------------------------------- ///
 
@@ -0,0 +1,212 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TenantError represents that the operation conflicts with the tenant scoping.
+type TenantError struct {
+	Key string // The tenant key, which may be scoped.
+	Op  string // The conflicting operation.
+}
+
+// Error implements the interface error.
+func (e TenantError) Error() string {
+	return fmt.Sprintf("conflicting tenant operation '%s' on the key '%s'", e.Op, e.Key)
+}
+
+// Tenant is used to scope the operations to a tenant in the multi-tenant
+// system, such as Tenant{Key: "tenant_id", Value: 123}.
+type Tenant struct {
+	Key   string
+	Value any
+
+	// If true, Inject returns a TenantError if the condition has contained
+	// a condition on the tenant key which is not Equal to Value.
+	Strict bool
+}
+
+// Scope returns a new tenant with the scoped key.
+func (t Tenant) Scope(name string) Tenant {
+	t.Key = Key(t.Key).Scope(name).Key
+	return t
+}
+
+// Condition returns the condition of the tenant, that is, Equal(t.Key, t.Value).
+func (t Tenant) Condition() Condition { return Equal(t.Key, t.Value) }
+
+// isKey reports whether the key is the tenant key of any scope.
+func (t Tenant) isKey(key string) bool {
+	return key == t.Key || strings.HasSuffix(key, Sep+t.Key)
+}
+
+// Inject returns a new condition by appending the tenant conditions
+// for all the scopes referred by the condition c, which are regarded as
+// the tables and returned by Scopes. For example,
+//
+//	t := Tenant{Key: "tenant_id", Value: 123}
+//	t.Inject(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)))
+//	=> And(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)), Equal("a.tenant_id", 123), Equal("b.tenant_id", 123))
+//
+// The tenant condition of a scope is not appended if c has implied it.
+// If c is nil, return the tenant condition without the scope.
+//
+// If c refers the keys both with and without the scope, return an error
+// wrapping ErrAmbiguousScope, since the key without the scope is ambiguous
+// in the SQL join.
+//
+// In the strict mode, if c contains any condition on the tenant key
+// other than Equal to the tenant value, such as Equal to other value,
+// In, or any condition in Not, or any expression referring the tenant key,
+// such as Col("tenant_id").NotEqual(123), return a TenantError.
+// But EqualKey between the tenant keys is allowed for the join.
+func (t Tenant) Inject(c Condition) (Condition, error) {
+	if t.Strict {
+		if err := t.checkCondition(c, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if c == nil {
+		return t.Condition(), nil
+	}
+
+	scopes := Scopes(c)
+	if err := checkScopes(scopes); err != nil {
+		return nil, fmt.Errorf("op.Tenant: %w", err)
+	}
+
+	conds := []Condition{c}
+	for _, scope := range scopes {
+		if cond := t.Scope(scope).Condition(); !implies(c, cond) {
+			conds = append(conds, cond)
+		}
+	}
+
+	if len(conds) == 1 {
+		return c, nil
+	}
+	return And(conds...), nil
+}
+
+func (t Tenant) checkCondition(c Condition, negated bool) error {
+	if c == nil {
+		return nil
+	}
+
+	o := c.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case CondOpAnd, CondOpOr:
+		conds, _ := o.Val.([]Condition)
+		for _, cond := range conds {
+			if err := t.checkCondition(cond, negated); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case CondOpNot:
+		cond, _ := o.Val.(Condition)
+		return t.checkCondition(cond, !negated)
+	}
+
+	if key, ok := t.exprKey(o.Val); ok {
+		return TenantError{Key: key, Op: o.Op}
+	}
+
+	if !t.isKey(o.Key) {
+		return nil
+	}
+
+	switch o.Op {
+	case CondOpEqual:
+		if r, ok := compareValues(o.Val, t.Value); !negated && ok && r == 0 {
+			return nil
+		}
+
+	case CondOpEqualKey:
+		if key, ok := o.Val.(string); !negated && ok && t.isKey(key) {
+			return nil
+		}
+	}
+
+	return TenantError{Key: o.Key, Op: o.Op}
+}
+
+// exprKey returns the tenant key referred by the expressions in the value,
+// which cannot be checked.
+func (t Tenant) exprKey(value any) (key string, ok bool) {
+	mapValue(reflect.ValueOf(value), func(v any) (any, bool) {
+		if e, _ok := v.(Expr); _ok && !ok {
+			for _, k := range e.Keys() {
+				if t.isKey(k) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		return nil, false
+	})
+	return
+}
+
+// CheckUpdaters checks whether the updaters modify the tenant key
+// of any scope, and returns a TenantError if true.
+//
+// For the upsert updater, the insert updaters may set the tenant key
+// to the tenant value, but the on-conflict updaters must not modify it.
+func (t Tenant) CheckUpdaters(ups ...Updater) error {
+	for _, up := range flattenUpdaters(ups) {
+		o := up.Op()
+		if o.Lazy != nil {
+			o = o.Lazy(o)
+		}
+
+		if v, ok := o.Val.(UpsertValue); ok && o.Op == UpdateOpUpsert {
+			for _, insert := range flattenUpdaters(v.Inserts) {
+				io := insert.Op()
+				if io.Lazy != nil {
+					io = io.Lazy(io)
+				}
+
+				if !t.isKey(io.Key) {
+					continue
+				}
+
+				if r, ok := compareValues(io.Val, t.Value); io.Op != UpdateOpSet || !ok || r != 0 {
+					return TenantError{Key: io.Key, Op: io.Op}
+				}
+			}
+
+			if err := t.CheckUpdaters(v.Updates...); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if t.isKey(o.Key) {
+			return TenantError{Key: o.Key, Op: o.Op}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTenantInject(t *testing.T) {
+	tenant := Tenant{Key: "tenant_id", Value: 123}
+	for i, c := range []struct {
+		cond   Condition
+		expect Condition
+	}{
+		{nil, Equal("tenant_id", 123)},
+		{Equal("id", 1), And(Equal("id", 1), Equal("tenant_id", 123))},
+		{
+			And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)),
+			And(And(EqualKey("a.id", "b.aid"), Equal("b.status", 1)), Equal("a.tenant_id", 123), Equal("b.tenant_id", 123)),
+		},
+		{
+			And(Equal("a.id", 1), Equal("a.tenant_id", int64(123)), Equal("b.id", 2)),
+			And(And(Equal("a.id", 1), Equal("a.tenant_id", int64(123)), Equal("b.id", 2)), Equal("b.tenant_id", 123)),
+		},
+		{
+			Equal("tenant_id", 456),
+			And(Equal("tenant_id", 456), Equal("tenant_id", 123)),
+		},
+	} {
+		if cond, err := tenant.Inject(c.cond); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(cond, c.expect) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, cond)
+		}
+	}
+
+	tenant.Strict = true
+	for i, c := range []struct {
+		cond Condition
+		err  bool
+	}{
+		{Equal("tenant_id", 123), false},
+		{And(Equal("a.id", 1), Equal("a.tenant_id", 123.0)), false},
+		{EqualKey("a.tenant_id", "b.tenant_id"), false},
+		{Equal("tenant_id", 456), true},
+		{Or(Equal("id", 1), Equal("a.tenant_id", 456)), true},
+		{In("tenant_id", []int{123, 456}), true},
+		{Not(Equal("tenant_id", 123)), true},
+		{IsNull("tenant_id"), true},
+		{EqualKey("a.tenant_id", "b.id"), true},
+		{Col("tenant_id").NotEqual(123), true},
+		{Col("a.tenant_id").Equal(123), true},
+		{Equal("id", Col("tenant_id").Add(1)), true},
+		{Col("price").Mul(Col("qty")).Greater(100), false},
+	} {
+		var terr TenantError
+		if _, err := tenant.Inject(c.cond); c.err != errors.As(err, &terr) {
+			t.Errorf("%d: expect error=%v, but got %v", i, c.err, err)
+		} else if !c.err && err != nil {
+			t.Errorf("%d: unexpected error %v", i, err)
+		}
+	}
+
+	_, err := Tenant{Key: "tenant_id", Value: 123}.Inject(And(EqualKey("a.id", "b.aid"), Equal("id", 1)))
+	if !errors.Is(err, ErrAmbiguousScope) {
+		t.Errorf("expect the error ErrAmbiguousScope, but got %v", err)
+	}
+}
+
+func TestTenantCheckUpdaters(t *testing.T) {
+	tenant := Tenant{Key: "tenant_id", Value: 123}
+	lazyTenant := Op{}.WithLazy(func(o Op) Op { return Set("tenant_id", 456).Op() }).Updater()
+	for i, c := range []struct {
+		ups []Updater
+		err bool
+	}{
+		{[]Updater{Set("name", "a"), Inc("count")}, false},
+		{[]Updater{Set("name", "a"), Set("tenant_id", 456)}, true},
+		{[]Updater{Batch(Set("name", "a"), Unset("t.tenant_id"))}, true},
+		{[]Updater{Upsert([]Updater{Set("id", 1), Set("tenant_id", 123)}, []string{"id"}, Set("name", "a"))}, false},
+		{[]Updater{Upsert([]Updater{Set("id", 1), Set("tenant_id", 456)}, []string{"id"})}, true},
+		{[]Updater{Upsert([]Updater{Set("id", 1)}, []string{"id"}, Set("tenant_id", 123))}, true},
+		{[]Updater{Upsert([]Updater{Set("id", 1), lazyTenant}, []string{"id"})}, true},
+	} {
+		var terr TenantError
+		if err := tenant.CheckUpdaters(c.ups...); c.err != errors.As(err, &terr) {
+			t.Errorf("%d: expect error=%v, but got %v", i, c.err, err)
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package op
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -131,3 +132,117 @@ func ExampleOp_Scope() {
 	// key
 	// T.key
 }
+
+func ExampleMergeArrayUpdaters() {
+	ups := MergeArrayUpdaters(
+		Push("tags", "a"),
+		Set("name", "Aaron"),
+		Batch(Push("tags", "b"), AddToSet("ids", 1, 2)),
+		AddToSet("ids", 2, 3),
+		Pull("tags", "a"),
+		Push("tags", "c"),
+	)
+
+	for _, up := range ups {
+		fmt.Println(up.Op())
+	}
+
+	// Output:
+	// Op(kind=Update, key=tags, op=Push, value=[a b])
+	// Op(kind=Update, key=name, op=Set, value=Aaron)
+	// Op(kind=Update, key=ids, op=AddToSet, value=[1 2 3])
+	// Op(kind=Update, key=tags, op=Pull, value=[a])
+	// Op(kind=Update, key=tags, op=Push, value=[c])
+}
+
+func ExampleCases_Resolve() {
+	up := Key("status").SetCase(When(Less("end_at", 100), "expired"), When(IsNull("end_at"), "forever"))
+	cases := up.Op().Val.(Cases)
+
+	for _, record := range []map[string]any{{"end_at": 50}, {"end_at": nil}, {"end_at": 200}} {
+		value, ok, _ := cases.Resolve(func(c Condition) (bool, error) {
+			switch op := c.Op(); op.Op {
+			case CondOpLess:
+				endAt, _ := record[op.Key].(int)
+				return record[op.Key] != nil && endAt < op.Val.(int), nil
+			case CondOpIsNull:
+				return record[op.Key] == nil, nil
+			default:
+				return false, fmt.Errorf("unsupported condition '%s'", op.Op)
+			}
+		})
+		fmt.Println(value, ok)
+	}
+
+	// Output:
+	// expired true
+	// forever true
+	// <nil> false
+}
+
+func ExampleWithVersion() {
+	ups, conds, err := WithVersion(3, []Updater{Set("name", "Aaron")}, []Condition{Eq("id", 123)})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, up := range ups {
+		fmt.Println(up.Op())
+	}
+	for _, cond := range conds {
+		fmt.Println(cond.Op())
+	}
+
+	_, _, err = WithVersion(3, []Updater{Batch(Set("name", "Aaron"), KeyVersion.Set(4))}, nil)
+	fmt.Println(err)
+
+	err = CheckVersionAffected(0, KeyVersion, 3)
+	fmt.Println(errors.Is(err, ErrVersionConflict))
+
+	// Output:
+	// Op(kind=Update, key=name, op=Set, value=Aaron)
+	// Op(kind=Update, key=version, op=Inc, value=<nil>)
+	// Op(kind=Condition, key=id, op=Equal, value=123)
+	// Op(kind=Condition, key=version, op=Equal, value=3)
+	// op.WithVersion: the version key 'version' has been updated
+	// true
+}
+
+func ExampleUpsert() {
+	up := Upsert(
+		SetKVs(KV{Key: "id", Val: 1}, KV{Key: "count", Val: 10}),
+		[]string{"id"},
+		Key("count").Add(Excluded("count")),
+		KeyUpdatedAt.SetExcluded(),
+	)
+
+	Walk(up, func(o Oper) bool {
+		if op := o.Op(); op.Op == UpdateOpUpsert {
+			fmt.Println(op.Op, op.Val.(UpsertValue).Conflicts)
+		} else {
+			fmt.Println(op)
+		}
+		return true
+	})
+
+	// Output:
+	// Upsert [id]
+	// Op(kind=Update, key=id, op=Set, value=1)
+	// Op(kind=Update, key=count, op=Set, value=10)
+	// Op(kind=Update, key=count, op=Add, value=count)
+	// Op(kind=Update, key=updated_at, op=Set, value=updated_at)
+}
+
+func ExampleWalk() {
+	up := Batch(Set("status", 1), PullIf("items", Eq("qty", 0)))
+	Walk(up, func(o Oper) bool {
+		fmt.Printf("%s(%s)\n", o.Op().Op, o.Op().Key)
+		return true
+	})
+
+	// Output:
+	// Batch()
+	// Set(status)
+	// Pull(items)
+}
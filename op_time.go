@@ -0,0 +1,133 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"strings"
+	"time"
+)
+
+// Clock is used to get the current time, which is used to resolve
+// the relative time and evaluate the function NOW.
+//
+// It may be replaced in the tests to fix the current time.
+var Clock = time.Now
+
+// RelTime represents a time relative to the current time returned by Clock,
+// which is used as the value and resolved to time.Time by ResolveTimes
+// when the operation is built or evaluated. For example,
+//
+//	KeyCreatedAt.GreaterEqual(Ago(7 * 24 * time.Hour))  // created in the last 7 days
+//	KeyExpiredAt.Less(FromNow(time.Hour))               // expires within 1 hour
+//	KeyCreatedAt.GreaterEqual(StartOfDay(time.UTC))     // created today
+type RelTime struct {
+	// If true, the base time is the start of the current day in Location.
+	// Or, it is the current time.
+	Day      bool
+	Location *time.Location // If nil, use time.Local.
+
+	// Offset is added to the base time.
+	Offset time.Duration
+}
+
+// Ago returns a relative time, which is d before the current time.
+func Ago(d time.Duration) RelTime { return RelTime{Offset: -d} }
+
+// FromNow returns a relative time, which is d after the current time.
+func FromNow(d time.Duration) RelTime { return RelTime{Offset: d} }
+
+// StartOfDay returns a relative time, which is the start of the current day
+// in the location. If loc is nil, use time.Local.
+func StartOfDay(loc *time.Location) RelTime { return RelTime{Day: true, Location: loc} }
+
+// Add returns a new relative time by adding d to the offset.
+func (t RelTime) Add(d time.Duration) RelTime {
+	t.Offset += d
+	return t
+}
+
+// Time resolves the relative time against the current time returned by Clock.
+func (t RelTime) Time() time.Time { return t.At(Clock()) }
+
+// At resolves the relative time against the given current time.
+func (t RelTime) At(now time.Time) time.Time {
+	if t.Day {
+		loc := t.Location
+		if loc == nil {
+			loc = time.Local
+		}
+
+		now = now.In(loc)
+		now = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	}
+	return now.Add(t.Offset)
+}
+
+// String returns the string representation of the relative time,
+// such as "now-24h0m0s" and "startofday(UTC)+1h0m0s".
+func (t RelTime) String() string {
+	var b strings.Builder
+	if t.Day {
+		b.WriteString("startofday(")
+		if t.Location == nil {
+			b.WriteString(time.Local.String())
+		} else {
+			b.WriteString(t.Location.String())
+		}
+		b.WriteByte(')')
+	} else {
+		b.WriteString("now")
+	}
+
+	if t.Offset > 0 {
+		b.WriteByte('+')
+	}
+	if t.Offset != 0 {
+		b.WriteString(t.Offset.String())
+	}
+	return b.String()
+}
+
+// ResolveTimes returns a new operation by resolving all the relative times
+// in the operation and its sub-operations against the current time
+// returned by Clock, which are resolved against the same current time.
+func ResolveTimes[T Oper](o T) T { return ResolveTimesAt(o, Clock()) }
+
+// ResolveTimesAt is the same as ResolveTimes, but resolves the relative times
+// against the given current time.
+func ResolveTimesAt[T Oper](o T, now time.Time) T {
+	return mapValues(o, func(v any) (any, bool) {
+		if t, ok := v.(RelTime); ok {
+			return t.At(now), true
+		}
+		return nil, false
+	})
+}
+
+// Within returns a new condition that the time of the key is
+// within the last duration d, which is equal to
+//
+//	o.Between(Ago(d), FromNow(0))
+func (o Op) Within(d time.Duration) Condition {
+	return o.Between(Ago(d), FromNow(0))
+}
+
+// WithinNext returns a new condition that the time of the key is
+// within the next duration d, which is equal to
+//
+//	o.Between(FromNow(0), FromNow(d))
+func (o Op) WithinNext(d time.Duration) Condition {
+	return o.Between(FromNow(0), FromNow(d))
+}
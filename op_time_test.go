@@ -0,0 +1,73 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRelTime(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 3, 15, 20, 30, 0, 0, time.UTC)
+	defer func(clock func() time.Time) { Clock = clock }(Clock)
+	Clock = func() time.Time { return now }
+
+	for i, c := range []struct {
+		rel    RelTime
+		expect time.Time
+		str    string
+	}{
+		{Ago(time.Hour), now.Add(-time.Hour), "now-1h0m0s"},
+		{FromNow(time.Hour), now.Add(time.Hour), "now+1h0m0s"},
+		{FromNow(0), now, "now"},
+		{StartOfDay(time.UTC), time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), "startofday(UTC)"},
+		{StartOfDay(loc), time.Date(2024, 3, 16, 0, 0, 0, 0, loc), "startofday(UTC+8)"},
+		{StartOfDay(time.UTC).Add(-24 * time.Hour), time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC), "startofday(UTC)-24h0m0s"},
+	} {
+		if v := c.rel.Time(); !v.Equal(c.expect) {
+			t.Errorf("%d: expect the time %v, but got %v", i, c.expect, v)
+		}
+		if s := c.rel.String(); s != c.str {
+			t.Errorf("%d: expect the string '%s', but got '%s'", i, c.str, s)
+		}
+	}
+
+	cond := And(KeyCreatedAt.Within(7*24*time.Hour), KeyExpiredAt.WithinNext(time.Hour))
+	expect := And(
+		KeyCreatedAt.Between(now.Add(-7*24*time.Hour), now),
+		KeyExpiredAt.Between(now, now.Add(time.Hour)),
+	)
+	if resolved := ResolveTimes(cond); !reflect.DeepEqual(resolved, expect) {
+		t.Errorf("expect %v, but got %v", expect, resolved)
+	}
+
+	if FingerprintString(KeyCreatedAt.Within(time.Hour)) == FingerprintString(KeyCreatedAt.Within(2*time.Hour)) {
+		t.Errorf("expect the different fingerprints")
+	}
+
+	if v, err := Col("created_at").Greater(Ago(time.Hour)).Op().Val.(Expr).Eval(func(string) (any, bool) {
+		return now.Add(-time.Minute), true
+	}); err != nil {
+		t.Error(err)
+	} else if v != true {
+		t.Errorf("expect true, but got %v", v)
+	}
+
+	if v, _ := Now().Eval(nil); v != now {
+		t.Errorf("expect %v, but got %v", now, v)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+// TypedKey is a key whose value has the fixed type T,
+// which is used to build the operations with the compile-time type checking.
+//
+// The untyped key is not embedded, so only the typed operations are exposed.
+// Use Op to build the other operations with the untyped key.
+type TypedKey[T any] struct{ key Op }
+
+// NewTypedKey returns a new typed key.
+func NewTypedKey[T any](key string) TypedKey[T] {
+	return TypedKey[T]{key: Key(key)}
+}
+
+// Key returns the name of the key.
+func (k TypedKey[T]) Key() string { return k.key.Key }
+
+// Op returns the untyped key.
+func (k TypedKey[T]) Op() Op { return k.key }
+
+// Scope is equal to NewTypedKey[T](k.Op().Scope(name).Key).
+func (k TypedKey[T]) Scope(name string) TypedKey[T] {
+	return TypedKey[T]{key: k.key.Scope(name)}
+}
+
+// Eq is equal to k.Op().Equal(value).
+func (k TypedKey[T]) Eq(value T) Condition { return k.key.Equal(value) }
+
+// NotEq is equal to k.Op().NotEqual(value).
+func (k TypedKey[T]) NotEq(value T) Condition { return k.key.NotEqual(value) }
+
+// Le is equal to k.Op().Less(value).
+func (k TypedKey[T]) Le(value T) Condition { return k.key.Less(value) }
+
+// LeEq is equal to k.Op().LessEqual(value).
+func (k TypedKey[T]) LeEq(value T) Condition { return k.key.LessEqual(value) }
+
+// Gt is equal to k.Op().Greater(value).
+func (k TypedKey[T]) Gt(value T) Condition { return k.key.Greater(value) }
+
+// GtEq is equal to k.Op().GreaterEqual(value).
+func (k TypedKey[T]) GtEq(value T) Condition { return k.key.GreaterEqual(value) }
+
+// In is equal to k.Op().In(values).
+func (k TypedKey[T]) In(values ...T) Condition { return k.key.In(values) }
+
+// NotIn is equal to k.Op().NotIn(values).
+func (k TypedKey[T]) NotIn(values ...T) Condition { return k.key.NotIn(values) }
+
+// Between is equal to k.Op().Between(lower, upper).
+func (k TypedKey[T]) Between(lower, upper T) Condition { return k.key.Between(lower, upper) }
+
+// IsNull is equal to k.Op().IsNull().
+func (k TypedKey[T]) IsNull() Condition { return k.key.IsNull() }
+
+// IsNotNull is equal to k.Op().IsNotNull().
+func (k TypedKey[T]) IsNotNull() Condition { return k.key.IsNotNull() }
+
+// Set is equal to k.Op().Set(value).
+func (k TypedKey[T]) Set(value T) Updater { return k.key.Set(value) }
+
+// Unset is equal to k.Op().Unset().
+func (k TypedKey[T]) Unset() Updater { return k.key.Unset() }
+
+// OrderAsc is equal to k.Op().OrderAsc().
+func (k TypedKey[T]) OrderAsc() Sorter { return k.key.OrderAsc() }
+
+// OrderDesc is equal to k.Op().OrderDesc().
+func (k TypedKey[T]) OrderDesc() Sorter { return k.key.OrderDesc() }
@@ -0,0 +1,43 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedKey(t *testing.T) {
+	key := NewTypedKey[int]("id").Scope("a")
+	if name := key.Key(); name != "a.id" {
+		t.Errorf("expect key 'a.id', but got '%s'", name)
+	}
+
+	for i, c := range []struct {
+		result Oper
+		expect Oper
+	}{
+		{key.Eq(1), Equal("a.id", 1)},
+		{key.In(1, 2), In("a.id", []int{1, 2})},
+		{key.Between(1, 2), Between("a.id", 1, 2)},
+		{key.IsNull(), IsNull("a.id")},
+		{key.Set(1), Set("a.id", 1)},
+		{key.OrderDesc(), Key("a.id").OrderDesc()},
+	} {
+		if !reflect.DeepEqual(c.result, c.expect) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expect, c.result)
+		}
+	}
+}
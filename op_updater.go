@@ -26,9 +26,25 @@ const (
 	UpdateOpSub   = "Sub"
 	UpdateOpMul   = "Mul"
 	UpdateOpDiv   = "Div"
+
+	UpdateOpUnset     = "Unset"     // key = NULL
+	UpdateOpMin       = "Min"       // key = LEAST(key, value)
+	UpdateOpMax       = "Max"       // key = GREATEST(key, value)
+	UpdateOpMod       = "Mod"       // key = key % value
+	UpdateOpConcat    = "Concat"    // key = CONCAT(key, value)
+	UpdateOpSetIfNull = "SetIfNull" // key = COALESCE(key, value)
 )
 
 // KV represents a key-value pair.
+//
+// As the value of the updater, it represents that the updater is based on
+// the other key KV.Key, such as AddKey, instead of the key of the updater.
+// That is, KV.Key is the base operand in place of the key, and KV.Val is
+// the other operand, for example,
+//
+//	Key("a").AddKey("b", 1)    // a = b + 1
+//	Key("a").MinKey("b", 1)    // a = LEAST(b, 1)
+//	Key("a").ConcatKey("b", 1) // a = CONCAT(b, 1)
 type KV struct {
 	Key string
 	Val any
@@ -96,6 +112,36 @@ func Set(key string, value any) Updater {
 	return Key(key).Set(value)
 }
 
+// Unset is equal to Key(key).Unset().
+func Unset(key string) Updater {
+	return Key(key).Unset()
+}
+
+// Min is equal to Key(key).Min(value).
+func Min(key string, value any) Updater {
+	return Key(key).Min(value)
+}
+
+// Max is equal to Key(key).Max(value).
+func Max(key string, value any) Updater {
+	return Key(key).Max(value)
+}
+
+// Mod is equal to Key(key).Mod(value).
+func Mod(key string, value any) Updater {
+	return Key(key).Mod(value)
+}
+
+// Concat is equal to Key(key).Concat(value).
+func Concat(key string, value any) Updater {
+	return Key(key).Concat(value)
+}
+
+// SetIfNull is equal to Key(key).SetIfNull(value).
+func SetIfNull(key string, value any) Updater {
+	return Key(key).SetIfNull(value)
+}
+
 // Inc is equal to o.WithOp(UpdateOpInc).Updater().
 func (o Op) Inc() Updater {
 	return o.WithOp(UpdateOpInc).Updater()
@@ -131,6 +177,41 @@ func (o Op) Set(value any) Updater {
 	return o.WithOp(UpdateOpSet).WithValue(value).Updater()
 }
 
+// Unset is equal to o.WithOp(UpdateOpUnset).WithValue(nil).Updater().
+func (o Op) Unset() Updater {
+	return o.WithOp(UpdateOpUnset).WithValue(nil).Updater()
+}
+
+// Min is equal to o.WithOp(UpdateOpMin).WithValue(value).Updater(),
+// which updates the key to the smaller of itself and value.
+func (o Op) Min(value any) Updater {
+	return o.WithOp(UpdateOpMin).WithValue(value).Updater()
+}
+
+// Max is equal to o.WithOp(UpdateOpMax).WithValue(value).Updater(),
+// which updates the key to the greater of itself and value.
+func (o Op) Max(value any) Updater {
+	return o.WithOp(UpdateOpMax).WithValue(value).Updater()
+}
+
+// Mod is equal to o.WithOp(UpdateOpMod).WithValue(value).Updater(),
+// which updates the key to the remainder of itself divided by value.
+func (o Op) Mod(value any) Updater {
+	return o.WithOp(UpdateOpMod).WithValue(value).Updater()
+}
+
+// Concat is equal to o.WithOp(UpdateOpConcat).WithValue(value).Updater(),
+// which appends value to the end of the key.
+func (o Op) Concat(value any) Updater {
+	return o.WithOp(UpdateOpConcat).WithValue(value).Updater()
+}
+
+// SetIfNull is equal to o.WithOp(UpdateOpSetIfNull).WithValue(value).Updater(),
+// which updates the key to value only if the key is NULL.
+func (o Op) SetIfNull(value any) Updater {
+	return o.WithOp(UpdateOpSetIfNull).WithValue(value).Updater()
+}
+
 // SetKey is equal to o.WithOp(UpdateOpSet).WithValue(KV{Key: key}).Updater().
 func (o Op) SetKey(key string) Updater {
 	return o.WithOp(UpdateOpSet).WithValue(KV{Key: key}).Updater()
@@ -155,3 +236,48 @@ func (o Op) MulKey(key string, value any) Updater {
 func (o Op) DivKey(key string, value any) Updater {
 	return o.WithOp(UpdateOpDiv).WithValue(KV{Key: key, Val: value}).Updater()
 }
+
+// MinKey is equal to o.WithOp(UpdateOpMin).WithValue(KV{Key: key, Val: value}).Updater(),
+// which updates the key to the smaller of the other key and value.
+func (o Op) MinKey(key string, value any) Updater {
+	return o.WithOp(UpdateOpMin).WithValue(KV{Key: key, Val: value}).Updater()
+}
+
+// MaxKey is equal to o.WithOp(UpdateOpMax).WithValue(KV{Key: key, Val: value}).Updater(),
+// which updates the key to the greater of the other key and value.
+func (o Op) MaxKey(key string, value any) Updater {
+	return o.WithOp(UpdateOpMax).WithValue(KV{Key: key, Val: value}).Updater()
+}
+
+// ModKey is equal to o.WithOp(UpdateOpMod).WithValue(KV{Key: key, Val: value}).Updater(),
+// which updates the key to the remainder of the other key divided by value.
+func (o Op) ModKey(key string, value any) Updater {
+	return o.WithOp(UpdateOpMod).WithValue(KV{Key: key, Val: value}).Updater()
+}
+
+// ConcatKey is equal to o.WithOp(UpdateOpConcat).WithValue(KV{Key: key, Val: value}).Updater(),
+// which updates the key to the concatenation of the other key and value.
+func (o Op) ConcatKey(key string, value any) Updater {
+	return o.WithOp(UpdateOpConcat).WithValue(KV{Key: key, Val: value}).Updater()
+}
+
+// SetIfNullKey is equal to o.WithOp(UpdateOpSetIfNull).WithValue(KV{Key: key, Val: value}).Updater(),
+// which updates the key to the other key, or value if the other key is NULL.
+func (o Op) SetIfNullKey(key string, value any) Updater {
+	return o.WithOp(UpdateOpSetIfNull).WithValue(KV{Key: key, Val: value}).Updater()
+}
+
+// flattenUpdaters flattens the nested batch updaters and drops the nil.
+func flattenUpdaters(ups []Updater) []Updater {
+	_ups := make([]Updater, 0, len(ups))
+	for _, up := range ups {
+		if up == nil {
+			continue
+		} else if op := up.Op(); op.Op == UpdateOpBatch {
+			_ups = append(_ups, flattenUpdaters(op.Val.([]Updater))...)
+		} else {
+			_ups = append(_ups, up)
+		}
+	}
+	return _ups
+}
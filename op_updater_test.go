@@ -0,0 +1,84 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdaterOps(t *testing.T) {
+	for i, c := range []struct {
+		up  Updater
+		op  string
+		key string
+		val any
+	}{
+		{Unset("a"), UpdateOpUnset, "a", nil},
+		{Min("a", 1), UpdateOpMin, "a", 1},
+		{Max("a", 1), UpdateOpMax, "a", 1},
+		{Mod("a", 3), UpdateOpMod, "a", 3},
+		{Concat("a", "x"), UpdateOpConcat, "a", "x"},
+		{SetIfNull("a", 0), UpdateOpSetIfNull, "a", 0},
+
+		{Key("a").AddKey("b", 1), UpdateOpAdd, "a", KV{Key: "b", Val: 1}},
+		{Key("a").MinKey("b", 1), UpdateOpMin, "a", KV{Key: "b", Val: 1}},
+		{Key("a").MaxKey("b", 1), UpdateOpMax, "a", KV{Key: "b", Val: 1}},
+		{Key("a").ModKey("b", 3), UpdateOpMod, "a", KV{Key: "b", Val: 3}},
+		{Key("a").ConcatKey("b", "x"), UpdateOpConcat, "a", KV{Key: "b", Val: "x"}},
+		{Key("a").SetIfNullKey("b", 0), UpdateOpSetIfNull, "a", KV{Key: "b", Val: 0}},
+	} {
+		o := c.up.Op()
+		if o.Kind != KindUpdate {
+			t.Errorf("%d: expect kind '%s', but got '%s'", i, KindUpdate, o.Kind)
+		}
+		if o.Op != c.op {
+			t.Errorf("%d: expect op '%s', but got '%s'", i, c.op, o.Op)
+		}
+		if o.Key != c.key {
+			t.Errorf("%d: expect key '%s', but got '%s'", i, c.key, o.Key)
+		}
+		if !reflect.DeepEqual(o.Val, c.val) {
+			t.Errorf("%d: expect value %v, but got %v", i, c.val, o.Val)
+		}
+	}
+}
+
+func TestNormalizeUpdaterOps(t *testing.T) {
+	ups, err := NormalizeUpdaters(
+		Min("a", 3), Min("a", 1), Max("b", 3), Max("b", 5),
+		Concat("c", "x"), Concat("c", "y"), Unset("d"), Unset("d"),
+		Mod("e", 3), Mod("e", 3), SetIfNull("f", 0), SetIfNull("f", 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []Updater{Min("a", 1), Max("b", 5), Concat("c", "xy"), Unset("d"), Mod("e", 3), SetIfNull("f", 0)}
+	if !reflect.DeepEqual(ups, expects) {
+		t.Errorf("expect %v, but got %v", expects, ups)
+	}
+
+	for i, ups := range [][]Updater{
+		{Mod("a", 3), Mod("a", 2)},
+		{SetIfNull("a", 0), SetIfNull("a", 1)},
+		{Min("a", 1), Max("a", 2)},
+		{Key("a").MinKey("b", 1), Key("a").MinKey("c", 1)},
+	} {
+		if _, err := NormalizeUpdaters(ups...); err == nil {
+			t.Errorf("%d: expect an error, but got nil", i)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+// UpdateOpUpsert is the operation to insert a record, or update it on conflict,
+// such as "INSERT ... ON CONFLICT ... DO UPDATE" or "ON DUPLICATE KEY UPDATE".
+//
+// Its key is empty and its value is UpsertValue.
+const UpdateOpUpsert = "Upsert"
+
+// Excluded is used as the value of the on-conflict updater to reference
+// the value of the key proposed for the insertion, such as EXCLUDED.key
+// of PostgreSQL or VALUES(key) of MySQL.
+//
+// For example,
+//
+//	Set("count", Excluded("count"))  // count = EXCLUDED.count
+//	Add("count", Excluded("count"))  // count = count + EXCLUDED.count
+type Excluded string
+
+// UpsertValue is the value of the upsert operation.
+type UpsertValue struct {
+	// Inserts is the values to be inserted, which are the Set updaters.
+	Inserts []Updater
+
+	// Conflicts is the keys of the conflict target, which may be empty
+	// for the backend detecting the conflict by itself, such as MySQL.
+	Conflicts []string
+
+	// Updates is the updaters executed on conflict.
+	//
+	// If empty, do nothing on conflict.
+	Updates []Updater
+}
+
+// Opers implements the interface Composite, which returns the insert
+// updaters followed by the on-conflict updaters.
+func (v UpsertValue) Opers() []Oper {
+	opers := make([]Oper, 0, len(v.Inserts)+len(v.Updates))
+	opers = append(opers, toOpers(v.Inserts)...)
+	opers = append(opers, toOpers(v.Updates)...)
+	return opers
+}
+
+// DoNothing reports whether to do nothing on conflict.
+func (v UpsertValue) DoNothing() bool { return len(v.Updates) == 0 }
+
+// Upsert returns a new upsert updater.
+//
+// inserts is the values to be inserted, which should be the Set updaters.
+// conflicts is the keys of the conflict target. updates is the updaters
+// executed on conflict, which does nothing on conflict if empty.
+func Upsert(inserts []Updater, conflicts []string, updates ...Updater) Updater {
+	return New(UpdateOpUpsert, "", UpsertValue{
+		Inserts:   inserts,
+		Conflicts: conflicts,
+		Updates:   updates,
+	}).Updater()
+}
+
+// UpsertKVs is the same as Upsert, but uses the key-value pairs as the insert values.
+func UpsertKVs(kvs []KV, conflicts []string, updates ...Updater) Updater {
+	return Upsert(SetKVs(kvs...), conflicts, updates...)
+}
+
+// SetKVs converts the key-value pairs to the Set updaters.
+func SetKVs(kvs ...KV) []Updater {
+	ups := make([]Updater, len(kvs))
+	for i, kv := range kvs {
+		ups[i] = Set(kv.Key, kv.Val)
+	}
+	return ups
+}
+
+// SetExcluded is equal to o.Set(Excluded(o.Key)), which updates the key
+// to the value proposed for the insertion on conflict.
+func (o Op) SetExcluded() Updater {
+	return o.Set(Excluded(o.Key))
+}
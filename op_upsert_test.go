@@ -0,0 +1,45 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpsertWithoutConflicts(t *testing.T) {
+	for i, up := range []Updater{
+		Upsert([]Updater{Set("id", 1)}, nil),
+		Upsert([]Updater{Set("id", 1)}, []string{}, Inc("count")),
+		UpsertKVs([]KV{{Key: "id", Val: 1}}, nil, Inc("count")),
+	} {
+		o := up.Op()
+		v, ok := o.Val.(UpsertValue)
+		if !ok || o.Op != UpdateOpUpsert || o.Key != "" || len(v.Conflicts) != 0 {
+			t.Errorf("%d: unexpected upsert %+v", i, o)
+			continue
+		}
+
+		if doNothing := len(v.Updates) == 0; v.DoNothing() != doNothing {
+			t.Errorf("%d: expect DoNothing=%v, but got %v", i, doNothing, v.DoNothing())
+		}
+
+		if ups, err := NormalizeUpdaters(up, up); err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if !reflect.DeepEqual(ups, []Updater{up, up}) {
+			t.Errorf("%d: expect the upserts kept, but got %v", i, ups)
+		}
+	}
+}
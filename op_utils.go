@@ -17,13 +17,15 @@ package op
 import "unicode/utf8"
 
 // StrBytesLen returns a lazy function to limit the byte length of a string to n.
+//
+// The value which is not a string, such as the unbound Param, is kept.
 func StrBytesLen(n int) Lazy {
 	if n <= 0 {
 		panic("op.StrBytesLen: n must be a positive negative")
 	}
 
 	return func(o Op) Op {
-		if s := o.Val.(string); len(s) > n {
+		if s, ok := o.Val.(string); ok && len(s) > n {
 			o.Val = s[:n]
 		}
 		return o
@@ -31,13 +33,15 @@ func StrBytesLen(n int) Lazy {
 }
 
 // StrCharsLen returns a lazy function to limit the character length of a string to n.
+//
+// The value which is not a string, such as the unbound Param, is kept.
 func StrCharsLen(n int) Lazy {
 	if n <= 0 {
 		panic("op.StrCharsLen: n must be a positive negative")
 	}
 
 	return func(o Op) Op {
-		if s := o.Val.(string); len(s) > n && utf8.RuneCountInString(s) > n {
+		if s, ok := o.Val.(string); ok && len(s) > n && utf8.RuneCountInString(s) > n {
 			var m int
 			for i := range s {
 				if m < n {
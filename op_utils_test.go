@@ -14,7 +14,10 @@
 
 package op
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestStrCharsLen(t *testing.T) {
 	op := StrCharsLen(8)(New("", "", "chinese中国china"))
@@ -24,3 +27,19 @@ func TestStrCharsLen(t *testing.T) {
 		t.Errorf("expect '%s', but got '%s'", expect, s)
 	}
 }
+
+func TestStrLenLazyParam(t *testing.T) {
+	for i, lazy := range []Lazy{StrBytesLen(3), StrCharsLen(3)} {
+		cond := Key("name").WithLazy(lazy).Equal(Param("name"))
+		if o := cond.Op(); !reflect.DeepEqual(o.Lazy(o).Val, Param("name")) {
+			t.Errorf("%d: expect the param kept, but got %v", i, o.Lazy(o).Val)
+		}
+
+		bound, err := Bind(cond, map[string]any{"name": "abcdef"})
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if o := bound.Op(); o.Lazy(o).Val != "abc" {
+			t.Errorf("%d: expect '%s', but got '%v'", i, "abc", o.Lazy(o).Val)
+		}
+	}
+}
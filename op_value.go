@@ -0,0 +1,257 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// number is a numeric value, which is either an integer or a float.
+type number struct {
+	Int     int64
+	Float   float64
+	IsFloat bool
+}
+
+func intNumber(i int64) number { return number{Int: i} }
+
+// toNumber converts an integer or float value to number.
+func toNumber(v any) (n number, ok bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{Int: rv.Int()}, true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return number{Int: int64(u)}, true
+		}
+		return number{Float: float64(rv.Uint()), IsFloat: true}, true
+
+	case reflect.Float32, reflect.Float64:
+		return number{Float: rv.Float(), IsFloat: true}, true
+
+	default:
+		return
+	}
+}
+
+func (n number) float() float64 {
+	if n.IsFloat {
+		return n.Float
+	}
+	return float64(n.Int)
+}
+
+// add returns n+m. If the integer sum overflows, it is promoted to float.
+func (n number) add(m number) number {
+	if !n.IsFloat && !m.IsFloat {
+		if sum := n.Int + m.Int; (sum > n.Int) == (m.Int > 0) {
+			return number{Int: sum}
+		}
+	}
+	return number{Float: n.float() + m.float(), IsFloat: true}
+}
+
+// mul returns n*m. If the integer product overflows, it is promoted to float.
+func (n number) mul(m number) number {
+	if !n.IsFloat && !m.IsFloat {
+		product := n.Int * m.Int
+		if n.Int == 0 || (product/n.Int == m.Int && !(n.Int == -1 && m.Int == math.MinInt64)) {
+			return number{Int: product}
+		}
+	}
+	return number{Float: n.float() * m.float(), IsFloat: true}
+}
+
+// div returns n/m, which is the truncated division for the integers.
+// If m is zero, return false.
+func (n number) div(m number) (number, bool) {
+	if !n.IsFloat && !m.IsFloat {
+		switch {
+		case m.Int == 0:
+			return number{}, false
+		case n.Int == math.MinInt64 && m.Int == -1: // Overflow
+			return number{Float: -float64(n.Int), IsFloat: true}, true
+		default:
+			return number{Int: n.Int / m.Int}, true
+		}
+	}
+
+	if f := m.float(); f != 0 {
+		return number{Float: n.float() / f, IsFloat: true}, true
+	}
+	return number{}, false
+}
+
+// neg returns -n. If n is math.MinInt64, it is promoted to float.
+func (n number) neg() number {
+	if n.IsFloat || n.Int == math.MinInt64 {
+		return number{Float: -n.float(), IsFloat: true}
+	}
+	return number{Int: -n.Int}
+}
+
+func (n number) abs() number {
+	if n.sign() < 0 {
+		return n.neg()
+	}
+	return n
+}
+
+func (n number) sign() int {
+	switch f := n.float(); {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (n number) compare(m number) int {
+	if !n.IsFloat && !m.IsFloat {
+		switch {
+		case n.Int < m.Int:
+			return -1
+		case n.Int > m.Int:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	switch a, b := n.float(), m.float(); {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// value converts the number to the value of the type t.
+//
+// If t is nil, return int for the integer or float64 for the float.
+// If t is not a numeric type or the number cannot be represented by t,
+// return int64 for the integer or float64 for the float.
+func (n number) value(t reflect.Type) any {
+	var v reflect.Value
+	if n.IsFloat {
+		v = reflect.ValueOf(n.Float)
+	} else {
+		v = reflect.ValueOf(n.Int)
+	}
+
+	if t == nil {
+		if !n.IsFloat && n.Int >= math.MinInt && n.Int <= math.MaxInt {
+			return int(n.Int)
+		}
+		return v.Interface()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !n.IsFloat && !reflect.Zero(t).OverflowInt(n.Int) {
+			return v.Convert(t).Interface()
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !n.IsFloat && n.Int >= 0 && !reflect.Zero(t).OverflowUint(uint64(n.Int)) {
+			return v.Convert(t).Interface()
+		}
+
+	case reflect.Float32, reflect.Float64:
+		return v.Convert(t).Interface()
+	}
+
+	return v.Interface()
+}
+
+// commonType returns the common type of all the non-nil values.
+//
+// If the types of the values are not consistent, return nil.
+func commonType(values ...any) (t reflect.Type) {
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+
+		if vt := reflect.TypeOf(v); t == nil {
+			t = vt
+		} else if t != vt {
+			return nil
+		}
+	}
+	return
+}
+
+// compareValues compares two values, which must be both numbers,
+// strings, bools or time.Time. Or, return false.
+//
+// Param is not comparable, since its value is unknown until bound.
+func compareValues(a, b any) (int, bool) {
+	if _, ok := a.(Param); ok {
+		return 0, false
+	}
+	if _, ok := b.(Param); ok {
+		return 0, false
+	}
+
+	if n, ok := toNumber(a); ok {
+		if m, ok := toNumber(b); ok {
+			return n.compare(m), true
+		}
+		return 0, false
+	}
+
+	if t1, ok := a.(time.Time); ok {
+		if t2, ok := b.(time.Time); ok {
+			switch {
+			case t1.Before(t2):
+				return -1, true
+			case t1.After(t2):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+		return 0, false
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return strings.Compare(va.String(), vb.String()), true
+
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		switch x, y := va.Bool(), vb.Bool(); {
+		case x == y:
+			return 0, true
+		case y:
+			return -1, true
+		default:
+			return 1, true
+		}
+
+	default:
+		return 0, false
+	}
+}
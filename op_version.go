@@ -0,0 +1,91 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict represents that the version has been changed by others
+// when updating the versioned record with the optimistic locking.
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError is the error returned by the builder
+// when no record is affected by the versioned update.
+//
+// errors.Is(err, ErrVersionConflict) returns true for it.
+type VersionConflictError struct {
+	Key     string
+	Version any
+}
+
+// Error implements the interface error.
+func (e VersionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: the key '%s' is not equal to %v", e.Key, e.Version)
+}
+
+// Is reports whether err is ErrVersionConflict.
+func (e VersionConflictError) Is(err error) bool { return err == ErrVersionConflict }
+
+// WithVersion is equal to WithVersionKey(KeyVersion, version, ups, conds).
+func WithVersion(version any, ups []Updater, conds []Condition) ([]Updater, []Condition, error) {
+	return withVersionKey("op.WithVersion", KeyVersion, version, ups, conds)
+}
+
+// WithVersionKey returns the new updaters and conditions for the optimistic
+// locking, which appends key.Inc() to the updaters and key.Eq(version)
+// to the conditions.
+//
+// If the updaters, including the nested ones in the batch, have updated key,
+// or the conditions, including the nested ones, have referred key,
+// return an error.
+func WithVersionKey(key Op, version any, ups []Updater, conds []Condition) ([]Updater, []Condition, error) {
+	return withVersionKey("op.WithVersionKey", key, version, ups, conds)
+}
+
+func withVersionKey(name string, key Op, version any, ups []Updater, conds []Condition) ([]Updater, []Condition, error) {
+	if ContainsKey(flattenUpdaters(ups), key.Key) {
+		return nil, nil, fmt.Errorf("%s: the version key '%s' has been updated", name, key.Key)
+	}
+
+	var conditioned bool
+	for _, cond := range conds {
+		condKeys(cond, func(k string) { conditioned = conditioned || k == key.Key })
+	}
+	if conditioned {
+		return nil, nil, fmt.Errorf("%s: the version key '%s' has been conditioned", name, key.Key)
+	}
+
+	_ups := make([]Updater, 0, len(ups)+1)
+	_ups = append(_ups, ups...)
+	_ups = append(_ups, key.Inc())
+
+	_conds := make([]Condition, 0, len(conds)+1)
+	_conds = append(_conds, conds...)
+	_conds = append(_conds, key.Eq(version))
+
+	return _ups, _conds, nil
+}
+
+// CheckVersionAffected returns a VersionConflictError if affected is equal to 0,
+// which is the number of the records affected by the versioned update.
+// Or, return nil.
+func CheckVersionAffected(affected int64, key Op, version any) error {
+	if affected == 0 {
+		return VersionConflictError{Key: key.Key, Version: version}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithVersion(t *testing.T) {
+	for i, c := range []struct {
+		ups   []Updater
+		conds []Condition
+		err   string
+	}{
+		{[]Updater{Set("name", "a")}, []Condition{Eq("id", 1)}, ""},
+		{[]Updater{KeyVersion.Inc()}, nil, "op.WithVersion: the version key 'version' has been updated"},
+		{[]Updater{Set("name", "a")}, []Condition{Eq("version", 2)}, "op.WithVersion: the version key 'version' has been conditioned"},
+		{nil, []Condition{Or(Eq("id", 1), Gt("version", 2))}, "has been conditioned"},
+		{nil, []Condition{EqualKey("id", "version")}, "has been conditioned"},
+	} {
+		ups, conds, err := WithVersion(3, c.ups, c.conds)
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%d: unexpected error %v", i, err)
+		case c.err == "" && (len(ups) != len(c.ups)+1 || len(conds) != len(c.conds)+1):
+			t.Errorf("%d: unexpected updaters %v or conditions %v", i, ups, conds)
+		case c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)):
+			t.Errorf("%d: expect the error containing '%s', but got %v", i, c.err, err)
+		}
+	}
+
+	_, _, err := WithVersionKey(Key("rev"), 1, nil, []Condition{Eq("rev", 1)})
+	if expect := "op.WithVersionKey: the version key 'rev' has been conditioned"; err == nil || err.Error() != expect {
+		t.Errorf("expect the error '%s', but got %v", expect, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+// Composite is the value of the composite operation
+// to return its sub-operations.
+type Composite interface {
+	Opers() []Oper
+}
+
+// SubOpers returns the sub-operations of the composite operation,
+// whose value is one of []Condition, []Updater, []Sorter, []Oper,
+// Condition and Composite. Or, return nil.
+//
+// The element condition of PullIf is not a sub-operation, since its keys
+// are relative to the array elements instead of the record.
+func SubOpers(o Oper) []Oper {
+	op := o.Op()
+	if op.Op == UpdateOpPull {
+		return nil
+	}
+
+	switch v := op.Val.(type) {
+	case []Condition:
+		return toOpers(v)
+	case []Updater:
+		return toOpers(v)
+	case []Sorter:
+		return toOpers(v)
+	case []Oper:
+		return v
+	case Condition:
+		return []Oper{v}
+	case Composite:
+		return v.Opers()
+	default:
+		return nil
+	}
+}
+
+// Walk traverses the operation and its sub-operations in depth-first order.
+//
+// If visit returns false, the sub-operations of the current operation are skipped.
+func Walk(o Oper, visit func(Oper) bool) {
+	if o == nil || !visit(o) {
+		return
+	}
+
+	for _, sub := range SubOpers(o) {
+		Walk(sub, visit)
+	}
+}
+
+func toOpers[S ~[]E, E Oper](ops S) []Oper {
+	opers := make([]Oper, 0, len(ops))
+	for _, op := range ops {
+		if o := Oper(op); o != nil {
+			opers = append(opers, o)
+		}
+	}
+	return opers
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package op
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubOpers(t *testing.T) {
+	cond := Eq("qty", 0)
+	for i, c := range []struct {
+		oper    Oper
+		expects []Oper
+	}{
+		{Eq("id", 1), nil},
+		{And(Eq("a", 1), nil, Eq("b", 2)), []Oper{Eq("a", 1), Eq("b", 2)}},
+		{Not(cond), []Oper{cond}},
+		{Batch(Set("a", 1), PullIf("items", cond)), []Oper{Set("a", 1), PullIf("items", cond)}},
+		{PullIf("items", cond), nil},
+		{Pull("items", 1, 2), nil},
+		{SetCase("a", When(cond, 1), Else(2)), []Oper{cond}},
+		{Upsert([]Updater{Set("id", 1)}, nil, Inc("n")), []Oper{Set("id", 1), Inc("n")}},
+	} {
+		if subs := SubOpers(c.oper); !reflect.DeepEqual(subs, c.expects) {
+			t.Errorf("%d: expect %v, but got %v", i, c.expects, subs)
+		}
+	}
+
+	var keys []string
+	Walk(Batch(Set("a", 1), PullIf("items", And(cond, Eq("price", 1)))), func(o Oper) bool {
+		keys = append(keys, o.Op().Key)
+		return true
+	})
+	if expects := []string{"", "a", "items"}; !reflect.DeepEqual(keys, expects) {
+		t.Errorf("expect the walked keys %v, but got %v", expects, keys)
+	}
+}
@@ -0,0 +1,803 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rsql parses the RSQL/FIQL query to the condition,
+// and formats the condition to the RSQL query.
+package rsql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xgfone/go-op"
+	"github.com/xgfone/go-op/internal/syntax"
+)
+
+// ErrUnsupported represents that the condition has no RSQL equivalent.
+var ErrUnsupported = errors.New("unsupported by RSQL")
+
+// Error represents the error of the invalid RSQL query.
+type Error struct {
+	Pos int // The byte offset in the query, starting with 1.
+	Msg string
+}
+
+// Error implements the interface error.
+func (e Error) Error() string {
+	return fmt.Sprintf("invalid rsql at position %d: %s", e.Pos, e.Msg)
+}
+
+// Arg is the argument of the comparison.
+type Arg struct {
+	Text   string // The unquoted and unescaped text.
+	Quoted bool
+}
+
+// Value returns the value inferred from the argument.
+//
+// The quoted argument is always a string. For the unquoted argument,
+// "true" and "false" are parsed to bool, the integer is parsed to int64,
+// the decimal is parsed to float64, and others are the string.
+func (a Arg) Value() any {
+	if a.Quoted || a.Text == "" {
+		return a.Text
+	}
+
+	switch a.Text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if c := a.Text[0]; c != '-' && c != '+' && c != '.' && (c < '0' || c > '9') {
+		return a.Text
+	}
+
+	if !strings.ContainsAny(a.Text, ".eE") {
+		if i, err := strconv.ParseInt(a.Text, 10, 64); err == nil {
+			return i
+		}
+	}
+	if f, err := strconv.ParseFloat(a.Text, 64); err == nil {
+		return f
+	}
+	return a.Text
+}
+
+// Wildcard reports whether the argument contains the wildcard "*",
+// which must be unquoted.
+func (a Arg) Wildcard() bool {
+	return !a.Quoted && strings.Contains(a.Text, "*")
+}
+
+// Pattern returns the LIKE pattern of the argument, in which the wildcard
+// "*" is converted to "%", and "%", "_" and "\" are escaped by "\".
+func (a Arg) Pattern() string {
+	var b strings.Builder
+	b.Grow(len(a.Text) + 2)
+	for i := 0; i < len(a.Text); i++ {
+		switch c := a.Text[i]; c {
+		case '*':
+			if a.Quoted {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('%')
+			}
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Operator is used to build the condition of the comparison
+// by the selector and the arguments.
+//
+// The returned error is reported as the message of Error.
+type Operator func(selector string, args []Arg) (op.Condition, error)
+
+// DefaultParser is the default parser with the builtin operators.
+var DefaultParser = NewParser()
+
+// Parse is equal to DefaultParser.Parse(query).
+func Parse(query string) (op.Condition, error) {
+	return DefaultParser.Parse(query)
+}
+
+// Parser is used to parse the RSQL query.
+//
+// Register should be called only during the initialization,
+// and Parse is safe to be called concurrently.
+type Parser struct {
+	operators map[string]Operator
+}
+
+// NewParser returns a new parser with the builtin operators as follow:
+//
+//	==        Equal, or Like if the argument contains the unquoted wildcard "*"
+//	!=        NotEqual, or NotLike if the argument contains the unquoted wildcard "*"
+//	=lt=, <   Less
+//	=le=, <=  LessEqual
+//	=gt=, >   Greater
+//	=ge=, >=  GreaterEqual
+//	=in=      In
+//	=out=     NotIn
+//	=like=    Like, in which the unquoted wildcard "*" is converted to "%"
+//	=isnull=  IsNull if the argument is true, or IsNotNull if false
+func NewParser() *Parser {
+	p := &Parser{operators: make(map[string]Operator, 16)}
+
+	p.Register("==", func(selector string, args []Arg) (op.Condition, error) {
+		arg, err := singleArg(args)
+		switch {
+		case err != nil:
+			return nil, err
+		case arg.Wildcard():
+			return op.Like(selector, arg.Pattern()), nil
+		default:
+			return op.Equal(selector, arg.Value()), nil
+		}
+	})
+
+	p.Register("!=", func(selector string, args []Arg) (op.Condition, error) {
+		arg, err := singleArg(args)
+		switch {
+		case err != nil:
+			return nil, err
+		case arg.Wildcard():
+			return op.NotLike(selector, arg.Pattern()), nil
+		default:
+			return op.NotEqual(selector, arg.Value()), nil
+		}
+	})
+
+	p.registerComparison(op.Less, "=lt=", "<")
+	p.registerComparison(op.LessEqual, "=le=", "<=")
+	p.registerComparison(op.Greater, "=gt=", ">")
+	p.registerComparison(op.GreaterEqual, "=ge=", ">=")
+
+	p.Register("=in=", func(selector string, args []Arg) (op.Condition, error) {
+		return op.In(selector, argValues(args)), nil
+	})
+
+	p.Register("=out=", func(selector string, args []Arg) (op.Condition, error) {
+		return op.NotIn(selector, argValues(args)), nil
+	})
+
+	p.Register("=like=", func(selector string, args []Arg) (op.Condition, error) {
+		arg, err := singleArg(args)
+		if err != nil {
+			return nil, err
+		}
+		return op.Like(selector, arg.Pattern()), nil
+	})
+
+	p.Register("=isnull=", func(selector string, args []Arg) (op.Condition, error) {
+		arg, err := singleArg(args)
+		if err != nil {
+			return nil, err
+		}
+
+		switch arg.Value() {
+		case true:
+			return op.IsNull(selector), nil
+		case false:
+			return op.IsNotNull(selector), nil
+		default:
+			return nil, fmt.Errorf("expect true or false, but got '%s'", arg.Text)
+		}
+	})
+
+	return p
+}
+
+func (p *Parser) registerComparison(f func(string, any) op.Condition, names ...string) {
+	operator := func(selector string, args []Arg) (op.Condition, error) {
+		arg, err := singleArg(args)
+		if err != nil {
+			return nil, err
+		}
+		return f(selector, arg.Value()), nil
+	}
+
+	for _, name := range names {
+		p.Register(name, operator)
+	}
+}
+
+func singleArg(args []Arg) (Arg, error) {
+	if len(args) != 1 {
+		return Arg{}, fmt.Errorf("expect one argument, but got %d", len(args))
+	}
+	return args[0], nil
+}
+
+func argValues(args []Arg) []any {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value()
+	}
+	return values
+}
+
+// Register registers the comparison operator, which overrides
+// the existed one with the same name.
+//
+// The name must be "==", "!=", "<", "<=", ">", ">=", or the FIQL form "=name=",
+// in which name consists of the letters and "-", such as "=near=".
+// Or, panic.
+func (p *Parser) Register(name string, operator Operator) {
+	if !isOperator(name) {
+		panic(fmt.Errorf("rsql: invalid operator name '%s'", name))
+	}
+	if operator == nil {
+		panic(fmt.Errorf("rsql: the operator '%s' is nil", name))
+	}
+	p.operators[name] = operator
+}
+
+func isOperator(name string) bool {
+	switch name {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+
+	if len(name) < 3 || name[0] != '=' || name[len(name)-1] != '=' {
+		return false
+	}
+
+	for i := 1; i < len(name)-1; i++ {
+		if c := name[i]; c != '-' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// Parse parses the RSQL query to the condition, such as
+//
+//	name==foo*;age=gt=18,status=in=(a,b)
+//
+// The grammar is as follow:
+//
+//	or         = and { "," and }
+//	and        = constraint { ";" constraint }
+//	constraint = "(" or ")" | selector operator arguments
+//	arguments  = "(" argument { "," argument } ")" | argument
+//	argument   = unreserved | '"' string '"' | "'" string "'"
+//
+// ";" binds tighter than ",". The selector and the unquoted argument consist
+// of the characters except the reserved characters, that's, the whitespaces
+// and `"'();,=!~<>`. The quoted argument supports the escape character "\".
+//
+// If the query is empty, return (nil, nil).
+func (p *Parser) Parse(query string) (op.Condition, error) {
+	qp := &queryParser{operators: p.operators, src: query}
+	if qp.skipSpaces(); qp.pos == len(query) {
+		return nil, nil
+	}
+
+	cond, err := qp.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if qp.skipSpaces(); qp.pos < len(query) {
+		return nil, qp.errorf(qp.pos, "unexpected '%c'", qp.src[qp.pos])
+	}
+	return cond, nil
+}
+
+func isReserved(r rune) bool {
+	switch r {
+	case '"', '\'', '(', ')', ';', ',', '=', '!', '~', '<', '>':
+		return true
+	default:
+		return unicode.IsSpace(r)
+	}
+}
+
+type queryParser struct {
+	operators map[string]Operator
+	src       string
+	pos       int
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...any) error {
+	return Error{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) skipSpaces() {
+	for p.pos < len(p.src) {
+		r, n := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.pos += n
+	}
+}
+
+// peek skips the whitespaces and returns the next byte, or 0 for the end.
+func (p *queryParser) peek() byte {
+	if p.skipSpaces(); p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *queryParser) parseOr() (op.Condition, error) {
+	return syntax.ParseBinary(p.parseAnd, p.separator(','), op.Or)
+}
+
+func (p *queryParser) parseAnd() (op.Condition, error) {
+	return syntax.ParseBinary(p.parseConstraint, p.separator(';'), op.And)
+}
+
+// separator returns a function to consume the separator sep if it is the next byte.
+func (p *queryParser) separator(sep byte) func() (bool, error) {
+	return func() (bool, error) {
+		if p.peek() != sep {
+			return false, nil
+		}
+		p.pos++
+		return true, nil
+	}
+}
+
+func (p *queryParser) parseConstraint() (op.Condition, error) {
+	if p.peek() == '(' {
+		p.pos++
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ')' {
+			return nil, p.unexpected("')'")
+		}
+		p.pos++
+		return cond, nil
+	}
+
+	start := p.pos
+	selector := p.readUnreserved()
+	if selector == "" {
+		return nil, p.unexpected("a selector")
+	}
+
+	opos := p.pos
+	name := p.readOperator()
+	operator, ok := p.operators[name]
+	if !ok {
+		if name == "" {
+			return nil, p.unexpected("an operator")
+		}
+		return nil, p.errorf(opos, "unknown operator '%s'", name)
+	}
+
+	args, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := operator(selector, args)
+	if err != nil {
+		return nil, p.errorf(start, "%s", err)
+	}
+	return cond, nil
+}
+
+func (p *queryParser) unexpected(expect string) error {
+	if p.pos >= len(p.src) {
+		return p.errorf(p.pos, "expect %s, but got the end", expect)
+	}
+
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return p.errorf(p.pos, "expect %s, but got '%c'", expect, r)
+}
+
+func (p *queryParser) readUnreserved() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, n := utf8.DecodeRuneInString(p.src[p.pos:])
+		if isReserved(r) {
+			break
+		}
+		p.pos += n
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *queryParser) readOperator() string {
+	start := p.pos
+	if start >= len(p.src) {
+		return ""
+	}
+
+	switch p.src[start] {
+	case '!', '<', '>':
+		p.pos++
+		if p.pos < len(p.src) && p.src[p.pos] == '=' {
+			p.pos++
+		}
+
+	case '=':
+		for p.pos++; p.pos < len(p.src); p.pos++ {
+			c := p.src[p.pos]
+			if c == '=' {
+				p.pos++
+				break
+			} else if c != '-' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+				break
+			}
+		}
+	}
+
+	return p.src[start:p.pos]
+}
+
+func (p *queryParser) parseArguments() ([]Arg, error) {
+	if p.peek() != '(' {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Arg{arg}, nil
+	}
+
+	p.pos++
+	var args []Arg
+	for {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, p.unexpected("',' or ')'")
+		}
+	}
+}
+
+func (p *queryParser) parseArgument() (Arg, error) {
+	switch c := p.peek(); c {
+	case '"', '\'':
+		start := p.pos
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.src); p.pos++ {
+			switch ch := p.src[p.pos]; ch {
+			case c:
+				p.pos++
+				return Arg{Text: b.String(), Quoted: true}, nil
+
+			case '\\':
+				if p.pos+1 < len(p.src) {
+					p.pos++
+					ch = p.src[p.pos]
+				}
+				b.WriteByte(ch)
+
+			default:
+				b.WriteByte(ch)
+			}
+		}
+		return Arg{}, p.errorf(start, "unterminated string")
+
+	default:
+		text := p.readUnreserved()
+		if text == "" {
+			return Arg{}, p.unexpected("an argument")
+		}
+		return Arg{Text: text}, nil
+	}
+}
+
+/// ---------------------------------------------------------------------- ///
+
+// Format formats the condition to the RSQL query, which can be parsed
+// by Parse to the equal condition.
+//
+// Like and NotLike are formatted to "==" and "!=" with the wildcard "*",
+// so the pattern must not contain "_", or "*" together with "%". Like and
+// NotLike without "%" are formatted to "=like=" and "!=". Between and
+// NotBetween are formatted to the range comparisons. The *Key conditions
+// and Not are not supported.
+//
+// The value must be one of bool, string, integers, floats or time.Time.
+// time.Time is formatted as the quoted string with the layout time.RFC3339Nano.
+func Format(cond op.Condition) (string, error) {
+	var b strings.Builder
+	if err := formatCondition(&b, cond); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+var operators = map[string]string{
+	op.CondOpEqual:        "==",
+	op.CondOpNotEqual:     "!=",
+	op.CondOpLess:         "=lt=",
+	op.CondOpLessEqual:    "=le=",
+	op.CondOpGreater:      "=gt=",
+	op.CondOpGreaterEqual: "=ge=",
+	op.CondOpIn:           "=in=",
+	op.CondOpNotIn:        "=out=",
+}
+
+func formatCondition(b *strings.Builder, cond op.Condition) error {
+	if cond == nil {
+		return fmt.Errorf("rsql: the condition is nil")
+	}
+
+	o := cond.Op()
+	if o.Lazy != nil {
+		o = o.Lazy(o)
+	}
+
+	switch o.Op {
+	case op.CondOpAnd, op.CondOpOr:
+		conds, _ := o.Val.([]op.Condition)
+		if len(conds) == 0 {
+			return fmt.Errorf("rsql: %w: empty %s", ErrUnsupported, o.Op)
+		}
+
+		sep := byte(';')
+		if o.Op == op.CondOpOr {
+			sep = ','
+		}
+
+		for i, c := range conds {
+			if i > 0 {
+				b.WriteByte(sep)
+			}
+
+			paren := syntax.NeedParen(o.Op, c)
+			if paren {
+				b.WriteByte('(')
+			}
+			if err := formatCondition(b, c); err != nil {
+				return err
+			}
+			if paren {
+				b.WriteByte(')')
+			}
+		}
+		return nil
+
+	case op.CondOpIsNull:
+		b.WriteString(o.Key + "=isnull=true")
+		return nil
+
+	case op.CondOpIsNotNull:
+		b.WriteString(o.Key + "=isnull=false")
+		return nil
+
+	case op.CondOpEqual, op.CondOpNotEqual, op.CondOpLess,
+		op.CondOpLessEqual, op.CondOpGreater, op.CondOpGreaterEqual:
+		return formatComparison(b, o.Key, operators[o.Op], o.Val)
+
+	case op.CondOpIn, op.CondOpNotIn:
+		vs := reflect.ValueOf(o.Val)
+		if vs.Kind() != reflect.Slice && vs.Kind() != reflect.Array {
+			return fmt.Errorf("rsql: the value of %s must be a slice, but got %T", o.Op, o.Val)
+		} else if vs.Len() == 0 {
+			return fmt.Errorf("rsql: %w: empty %s", ErrUnsupported, o.Op)
+		}
+
+		b.WriteString(o.Key + operators[o.Op] + "(")
+		for i, _len := 0, vs.Len(); i < _len; i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := formatValue(b, vs.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(')')
+		return nil
+
+	case op.CondOpBetween, op.CondOpNotBetween:
+		v, ok := o.Val.(op.Boundary)
+		if !ok {
+			return fmt.Errorf("rsql: the value of %s must be a Boundary, but got %T", o.Op, o.Val)
+		}
+
+		lower, sep, upper := "=ge=", ";", "=le="
+		if o.Op == op.CondOpNotBetween {
+			lower, sep, upper = "=lt=", ",", "=gt="
+			b.WriteByte('(')
+		}
+
+		if err := formatComparison(b, o.Key, lower, v.Lower); err != nil {
+			return err
+		}
+		b.WriteString(sep)
+		if err := formatComparison(b, o.Key, upper, v.Upper); err != nil {
+			return err
+		}
+
+		if o.Op == op.CondOpNotBetween {
+			b.WriteByte(')')
+		}
+		return nil
+
+	case op.CondOpLike, op.CondOpNotLike:
+		pattern, ok := o.Val.(string)
+		if !ok {
+			return fmt.Errorf("rsql: the value of %s must be a string, but got %T", o.Op, o.Val)
+		}
+
+		text, wildcard, ok := likeToWildcard(pattern)
+		switch {
+		case !ok:
+			return fmt.Errorf("rsql: %w: like pattern '%s'", ErrUnsupported, pattern)
+
+		case !wildcard:
+			if o.Op == op.CondOpLike {
+				b.WriteString(o.Key + "=like=")
+			} else {
+				b.WriteString(o.Key + "!=")
+			}
+			quoteString(b, text)
+
+		case o.Op == op.CondOpLike:
+			b.WriteString(o.Key + "==" + text)
+
+		default:
+			b.WriteString(o.Key + "!=" + text)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("rsql: %w: condition operation '%s'", ErrUnsupported, o.Op)
+	}
+}
+
+func formatComparison(b *strings.Builder, key, operator string, value any) error {
+	if value == nil {
+		switch operator {
+		case "==":
+			b.WriteString(key + "=isnull=true")
+			return nil
+		case "!=":
+			b.WriteString(key + "=isnull=false")
+			return nil
+		default:
+			return fmt.Errorf("rsql: null cannot be compared by '%s'", operator)
+		}
+	}
+
+	b.WriteString(key + operator)
+	return formatValue(b, value)
+}
+
+// likeToWildcard converts the LIKE pattern to the argument, in which
+// "%" is converted to the wildcard "*", and returns false if failing.
+//
+// If wildcard is true, text is the unquoted argument. Or, it must be quoted.
+func likeToWildcard(pattern string) (text string, wildcard, ok bool) {
+	var b strings.Builder
+	b.Grow(len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			b.WriteByte('*')
+			wildcard = true
+		case '_':
+			return
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	text = b.String()
+	if wildcard && strings.ContainsAny(pattern, "*") {
+		return // The literal "*" cannot be in the unquoted argument.
+	} else if wildcard && strings.IndexFunc(text, isReserved) > -1 {
+		return
+	}
+	return text, wildcard, true
+}
+
+func formatValue(b *strings.Builder, value any) error {
+	switch v := value.(type) {
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		formatString(b, v)
+	case time.Time:
+		formatString(b, v.Format(time.RFC3339Nano))
+
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if math.IsInf(f, 0) || math.IsNaN(f) {
+				return fmt.Errorf("rsql: unsupported float value %v", f)
+			}
+
+			s := strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())
+			if !strings.ContainsAny(s, ".e") {
+				s += ".0"
+			}
+			b.WriteString(s)
+
+		case reflect.String:
+			formatString(b, rv.String())
+
+		case reflect.Bool:
+			b.WriteString(strconv.FormatBool(rv.Bool()))
+
+		default:
+			return fmt.Errorf("rsql: unsupported value type %T", value)
+		}
+	}
+	return nil
+}
+
+// formatString writes the string unquoted if it is parsed back
+// to the same string, or quoted by the double quotes.
+func formatString(b *strings.Builder, s string) {
+	if s != "" && strings.IndexFunc(s, isReserved) < 0 && !strings.Contains(s, "*") {
+		if v, ok := (Arg{Text: s}).Value().(string); ok && v == s {
+			b.WriteString(s)
+			return
+		}
+	}
+	quoteString(b, s)
+}
+
+func quoteString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/xgfone/go-op"
+)
+
+func TestParse(t *testing.T) {
+	cond, err := Parse(`name==foo*;age=gt=18,status=in=(a,b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := op.Or(
+		op.And(op.Like("name", "foo%"), op.Greater("age", int64(18))),
+		op.In("status", []any{"a", "b"}),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	cond, err = Parse(`title=="a \"*\" b";(score<=1.5,score>99);deleted_at=isnull=true;` +
+		`tag=out=("x,y",1,true);code=like=a_b*;email!=*@example.com;zip=="007"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect = op.And(
+		op.Equal("title", `a "*" b`),
+		op.Or(op.LessEqual("score", 1.5), op.Greater("score", int64(99))),
+		op.IsNull("deleted_at"),
+		op.NotIn("tag", []any{"x,y", int64(1), true}),
+		op.Like("code", `a\_b%`),
+		op.NotLike("email", "%@example.com"),
+		op.Equal("zip", "007"),
+	)
+	if !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	p := NewParser()
+	p.Register("=near=", func(selector string, args []Arg) (op.Condition, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("expect two arguments, but got %d", len(args))
+		}
+		return op.Between(selector, args[0].Value(), args[1].Value()), nil
+	})
+
+	cond, err := p.Parse("age=near=(10,20)")
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := op.Between("age", int64(10), int64(20)); !reflect.DeepEqual(cond, expect) {
+		t.Errorf("expect %v, but got %v", expect, cond)
+	}
+
+	_, err = p.Parse("a==1;age=near=1")
+	if expect := "invalid rsql at position 6: expect two arguments, but got 1"; err == nil || err.Error() != expect {
+		t.Errorf("expect error '%s', but got '%v'", expect, err)
+	}
+
+	if _, err = Parse("age=near=(10,20)"); err == nil || err.Error() != "invalid rsql at position 4: unknown operator '=near='" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for query, pos := range map[string]int{
+		`a`:            2,
+		`==1`:          1,
+		`a==`:          4,
+		`a==1;`:        6,
+		`(a==1`:        6,
+		`a=in=(1,2`:    10,
+		`a=="x`:        4,
+		`a=~1`:         2,
+		`a==1)`:        5,
+		`a==1,b=lt=()`: 12,
+		`a=isnull=x`:   1,
+		`a==(1,2)`:     1,
+	} {
+		_, err := Parse(query)
+		var e Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: expect an Error, but got %v", query, err)
+		} else if e.Pos != pos {
+			t.Errorf("%s: expect the position %d, but got %d: %s", query, pos, e.Pos, e.Msg)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range []struct {
+		cond   op.Condition
+		expect string
+	}{
+		{
+			op.And(op.Like("name", "foo%"), op.Or(op.Gt("age", 18), op.In("status", []string{"a", "b c"}))),
+			`name==foo*;(age=gt=18,status=in=(a,"b c"))`,
+		},
+		{
+			op.Or(op.And(op.Eq("a", "1"), op.Eq("b", 1.0)), op.NotBetween("c", 1, 2), op.Between("d", 1, 2)),
+			`a=="1";b==1.0,(c=lt=1,c=gt=2),d=ge=1;d=le=2`,
+		},
+		{
+			op.And(op.IsNull("a"), op.Eq("b", nil), op.NotLike("c", "x*"), op.Like("d", `50\%`), op.Eq("e", `a"*`)),
+			`a=isnull=true;b=isnull=true;c!="x*";d=like="50%";e=="a\"*"`,
+		},
+		{
+			op.And(op.Eq("a", 1), op.And(op.Eq("b", 2), op.Eq("c", 3)), op.Or(op.Eq("d", 4), op.Or(op.Eq("e", 5), op.Eq("f", 6)))),
+			`a==1;(b==2;c==3);(d==4,(e==5,f==6))`,
+		},
+	} {
+		s, err := Format(c.cond)
+		if err != nil {
+			t.Error(err)
+			continue
+		} else if s != c.expect {
+			t.Errorf("expect '%s', but got '%s'", c.expect, s)
+		}
+
+		if _, err := Parse(s); err != nil {
+			t.Errorf("fail to parse '%s': %v", s, err)
+		}
+	}
+
+	for _, cond := range []op.Condition{
+		op.Not(op.Eq("a", 1)),
+		op.EqualKey("a", "b"),
+		op.Like("a", "a_%"),
+		op.Like("a", "a*%"),
+		op.In("a", []int{}),
+	} {
+		if s, err := Format(cond); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("expect ErrUnsupported, but got '%s' and %v", s, err)
+		}
+	}
+}